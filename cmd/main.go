@@ -1,50 +1,171 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ptrciafae/hotels-merge/internal/hotels"
 	"github.com/ptrciafae/hotels-merge/internal/mapper"
 	"github.com/ptrciafae/hotels-merge/internal/server"
 )
 
+const usage = `usage: main [command]
+
+commands:
+  serve       fetch the suppliers and serve the merged hotels (default)
+  conflicts   fetch the suppliers once and print the fields they disagree on as JSON
+  unmatched   fetch the suppliers once and print the values missing from the vocabularies as JSON
+`
+
 func main() {
+	// cancelled on Ctrl+C / SIGTERM so a slow startup fetch can be interrupted cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	store := hotels.NewHotelStore()
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
 
+	switch command {
+	case "serve":
+		engine, config := loadConfig()
+		serve(ctx, engine, config)
+	case "conflicts":
+		engine, config := loadConfig()
+		engine.EnableConflictDetection()
+		printConflicts(ctx, engine, config)
+	case "unmatched":
+		engine, config := loadConfig()
+		printUnmatched(ctx, engine, config)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// loadConfig creates the mapping engine from mapping.json and loads the supplier configuration from config.json
+func loadConfig() (*mapper.MappingEngine, *hotels.Config) {
 	// load mapping configuration from file
 	file, err := os.Open("./mapping.json")
 	if err != nil {
-		fmt.Printf("error opening mapping file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "error opening mapping file: %v\n", err)
 		os.Exit(1)
 	}
 	defer file.Close()
 
 	mappingConfig, err := io.ReadAll(file)
 	if err != nil {
-		fmt.Printf("error reading mapping file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "error reading mapping file: %v\n", err)
 		os.Exit(1)
 	}
 
 	engine, err := mapper.NewMappingEngine(mappingConfig)
 	if err != nil {
-		fmt.Printf("error creating mapping engine: %v\n", err)
+		fmt.Fprintf(os.Stderr, "error creating mapping engine: %v\n", err)
+		os.Exit(1)
+	}
+
+	// load supplier configuration from file
+	configJSON, err := os.ReadFile("./config.json")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading config file: %v\n", err)
 		os.Exit(1)
 	}
 
-	hotels, err := hotels.FetchAndNormalize(engine)
+	config, err := hotels.LoadConfig(configJSON)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := config.Validate(engine); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid supplier config: %v\n", err)
+		os.Exit(1)
+	}
+	if config.Provenance {
+		engine.EnableProvenance()
+	}
+	if config.ConflictDetection {
+		engine.EnableConflictDetection()
+	}
+
+	return engine, config
+}
+
+// fetch runs a single ingestion, exiting when no hotels could be merged
+func fetch(ctx context.Context, engine *mapper.MappingEngine, config *hotels.Config) (*hotels.Dataset, *hotels.IngestionReport) {
+	result, report, err := hotels.FetchAndNormalize(ctx, engine, config)
+	report.Log()
 	if err != nil {
-		fmt.Printf("error fetching and normalizing hotels: %v\n", err)
+		fmt.Fprintf(os.Stderr, "error fetching and normalizing hotels: %v\n", err)
 		os.Exit(1)
 	}
-	store.Set(hotels)
+	return result, report
+}
+
+func serve(ctx context.Context, engine *mapper.MappingEngine, config *hotels.Config) {
+	store := hotels.NewHotelStore()
+
+	// the first refresh loads the hotels, the refresher keeps the supplier payloads to stand in for failing suppliers later
+	refresher := hotels.NewRefresher(store, engine, config)
+	if !refresher.Refresh(ctx) {
+		fmt.Fprintf(os.Stderr, "error fetching and normalizing hotels: %s\n", store.Report().Error)
+		os.Exit(1)
+	}
+
+	// keep the data fresh in the background, stops with ctx
+	go refresher.Run(ctx)
+
 	srv := server.New(store)
 
+	// shut the server down gracefully once a termination signal is received
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Stop(shutdownCtx); err != nil {
+			log.Printf("error stopping server: %v", err)
+		}
+	}()
+
 	log.Println("Server starting on :8085")
 	if err := srv.Start(); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// printConflicts writes the conflict report to stdout, logs go to stderr so the output can be piped
+func printConflicts(ctx context.Context, engine *mapper.MappingEngine, config *hotels.Config) {
+	result, _ := fetch(ctx, engine, config)
+
+	if err := printJSON(hotels.NewConflictReport(result.Conflicts)); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing conflict report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printUnmatched writes the supplier values missing from the vocabularies to stdout, most frequent first,
+// logs and errors go to stderr so the output can be piped
+func printUnmatched(ctx context.Context, engine *mapper.MappingEngine, config *hotels.Config) {
+	result, _ := fetch(ctx, engine, config)
+
+	if err := printJSON(hotels.NewUnmatchedReport(result.Unmatched)); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing unmatched report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printJSON writes an indented report to stdout
+func printJSON(report interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
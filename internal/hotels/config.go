@@ -0,0 +1,144 @@
+package hotels
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
+)
+
+const (
+	defaultSupplierTimeout      = 10 * time.Second
+	defaultMaxConcurrentFetches = 4
+)
+
+// Config represents the structure of config.json
+type Config struct {
+	MaxConcurrentFetches int           `json:"max_concurrent_fetches,omitempty"` // size of the worker pool fetching suppliers
+	Refresh              RefreshConfig `json:"refresh,omitempty"`
+	Provenance           bool          `json:"provenance,omitempty"`         // record where every merged value came from, see mapper.HotelProvenance
+	ConflictDetection    bool          `json:"conflict_detection,omitempty"` // record values the suppliers disagree on, see mapper.Conflict
+	Suppliers            []Supplier    `json:"suppliers"`
+}
+
+// Supplier describes a single supplier endpoint and how to call it
+// Timeout is the deadline of the whole fetch, all attempts and the waits between them included,
+// see RetryPolicy for how failed attempts are retried
+type Supplier struct {
+	Name    string            `json:"name"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Timeout Duration          `json:"timeout,omitempty"`
+	Retry   RetryPolicy       `json:"retry,omitempty"`
+	Enabled bool              `json:"enabled"`
+}
+
+// Duration is a time.Duration that is read from and written to JSON as a string, e.g. "10s"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("duration must be a string such as \"10s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadConfig parses config.json and fills in defaults for optional supplier settings
+func LoadConfig(configJSON []byte) (*Config, error) {
+	var config Config
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if config.MaxConcurrentFetches <= 0 {
+		config.MaxConcurrentFetches = defaultMaxConcurrentFetches
+	}
+	if config.Refresh.Interval < 0 || config.Refresh.Jitter < 0 || config.Refresh.MinSpacing < 0 {
+		return nil, fmt.Errorf("refresh interval, jitter and min_spacing must not be negative")
+	}
+	if config.Refresh.Interval > 0 && config.Refresh.Jitter >= config.Refresh.Interval {
+		return nil, fmt.Errorf("refresh jitter must be smaller than the interval")
+	}
+
+	seenName := make(map[string]bool)
+	for i := range config.Suppliers {
+		supplier := &config.Suppliers[i]
+
+		if strings.TrimSpace(supplier.Name) == "" {
+			return nil, fmt.Errorf("supplier #%d has no name", i+1)
+		}
+		if seenName[supplier.Name] {
+			return nil, fmt.Errorf("supplier %s is declared more than once", supplier.Name)
+		}
+		seenName[supplier.Name] = true
+
+		if strings.TrimSpace(supplier.URL) == "" {
+			return nil, fmt.Errorf("supplier %s has no url", supplier.Name)
+		}
+		if supplier.Method == "" {
+			supplier.Method = http.MethodGet
+		}
+		supplier.Method = strings.ToUpper(supplier.Method)
+		if supplier.Timeout <= 0 {
+			supplier.Timeout = Duration(defaultSupplierTimeout)
+		}
+		if supplier.Retry.Jitter < 0 || supplier.Retry.Jitter > 1 {
+			return nil, fmt.Errorf("supplier %s retry jitter must be between 0 and 1", supplier.Name)
+		}
+		supplier.Retry = supplier.Retry.withDefaults()
+	}
+
+	return &config, nil
+}
+
+// Validate cross-checks the declared suppliers against the suppliers referenced in the mapping
+func (c *Config) Validate(engine *mapper.MappingEngine) error {
+	idFieldMappings := engine.IdFieldMapping()
+
+	declared := make(map[string]bool)
+	for _, supplier := range c.Suppliers {
+		declared[supplier.Name] = true
+		if _, exists := idFieldMappings[supplier.Name]; !exists {
+			return fmt.Errorf("supplier %s has no id mapping in mapping config", supplier.Name)
+		}
+	}
+
+	var undeclared []string
+	for _, name := range engine.Suppliers() {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		slices.Sort(undeclared)
+		return fmt.Errorf("mapping config references undeclared supplier(s): %s", strings.Join(undeclared, ", "))
+	}
+
+	return nil
+}
+
+// EnabledSuppliers returns the suppliers that should be fetched
+func (c *Config) EnabledSuppliers() []Supplier {
+	var enabled []Supplier
+	for _, supplier := range c.Suppliers {
+		if supplier.Enabled {
+			enabled = append(enabled, supplier)
+		}
+	}
+	return enabled
+}
@@ -0,0 +1,136 @@
+package hotels_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ptrciafae/hotels-merge/internal/hotels"
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	config, err := hotels.LoadConfig([]byte(`{
+		"suppliers": [{"name": "acme", "url": "http://acme", "method": "post", "enabled": true}]
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, config.MaxConcurrentFetches)
+	require.Len(t, config.Suppliers, 1)
+	supplier := config.Suppliers[0]
+	assert.Equal(t, http.MethodPost, supplier.Method)
+	assert.Equal(t, hotels.Duration(10*time.Second), supplier.Timeout)
+	assert.Equal(t, hotels.RetryPolicy{
+		MaxAttempts: 1,
+		BackoffBase: hotels.Duration(200 * time.Millisecond),
+		BackoffCap:  hotels.Duration(5 * time.Second),
+		RetryOn:     []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}, supplier.Retry)
+
+	config, err = hotels.LoadConfig([]byte(`{"suppliers": [{"name": "acme", "url": "http://acme"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, config.Suppliers[0].Method)
+	assert.Empty(t, config.EnabledSuppliers())
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "empty name",
+			config: `{"suppliers": [{"name": " ", "url": "http://acme"}]}`,
+			err:    "supplier #1 has no name",
+		},
+		{
+			name:   "duplicate name",
+			config: `{"suppliers": [{"name": "acme", "url": "http://acme"}, {"name": "acme", "url": "http://other"}]}`,
+			err:    "supplier acme is declared more than once",
+		},
+		{
+			name:   "empty url",
+			config: `{"suppliers": [{"name": "acme", "url": ""}]}`,
+			err:    "supplier acme has no url",
+		},
+		{
+			name:   "duration without unit",
+			config: `{"suppliers": [{"name": "acme", "url": "http://acme", "timeout": "10"}]}`,
+			err:    `time: missing unit in duration "10"`,
+		},
+		{
+			name:   "duration as a number",
+			config: `{"suppliers": [{"name": "acme", "url": "http://acme", "retry": {"backoff_base": 500}}]}`,
+			err:    `duration must be a string such as "10s"`,
+		},
+		{
+			name:   "negative refresh interval",
+			config: `{"refresh": {"interval": "-1h"}, "suppliers": []}`,
+			err:    "refresh interval, jitter and min_spacing must not be negative",
+		},
+		{
+			name:   "jitter longer than the interval",
+			config: `{"refresh": {"interval": "1m", "jitter": "1m"}, "suppliers": []}`,
+			err:    "refresh jitter must be smaller than the interval",
+		},
+		{
+			name:   "retry jitter out of range",
+			config: `{"suppliers": [{"name": "acme", "url": "http://acme", "retry": {"jitter": 1.5}}]}`,
+			err:    "supplier acme retry jitter must be between 0 and 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := hotels.LoadConfig([]byte(tt.config))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		mapping   string
+		suppliers string
+		err       string // empty when the config is valid
+	}{
+		{
+			name:      "valid",
+			mapping:   `{"id": {"src::acme": "id", "src::patagonia": "id"}}`,
+			suppliers: `[{"name": "acme", "url": "http://acme"}, {"name": "patagonia", "url": "http://patagonia"}]`,
+		},
+		{
+			name:      "supplier without id mapping",
+			mapping:   `{"id": {"src::acme": "id"}}`,
+			suppliers: `[{"name": "acme", "url": "http://acme"}, {"name": "paperflies", "url": "http://paperflies"}]`,
+			err:       "supplier paperflies has no id mapping in mapping config",
+		},
+		{
+			name:      "src key of undeclared suppliers",
+			mapping:   `{"id": {"src::acme": "id", "src::patagonia": "id"}, "name": {"src::paperflies": "hotel_name"}}`,
+			suppliers: `[{"name": "acme", "url": "http://acme"}]`,
+			err:       "mapping config references undeclared supplier(s): paperflies, patagonia",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := mapper.NewMappingEngine([]byte(tt.mapping))
+			require.NoError(t, err)
+			config, err := hotels.LoadConfig([]byte(`{"suppliers": ` + tt.suppliers + `}`))
+			require.NoError(t, err)
+
+			err = config.Validate(engine)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package hotels
+
+import (
+	"encoding/json"
+
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
+)
+
+// Dataset is the outcome of merging the suppliers: the hotels and what the engine recorded while merging them
+type Dataset struct {
+	Hotels     Hotels
+	Provenance map[string]mapper.HotelProvenance // key: hotel id, nil unless provenance is enabled
+	Conflicts  []mapper.Conflict                 // nil unless conflict detection is enabled
+	Unmatched  []mapper.UnmatchedTerm            // supplier values missing from the mapping vocabularies
+
+	CoercionFailures []mapper.CoercionFailure // supplier values left out because they do not have the type of their field
+	Quarantined      []QuarantinedHotel       // merged hotels left out because they could not be decoded
+
+	payloads map[string]supplierPayload // key: supplier name, the responses the hotels were merged from
+}
+
+// QuarantinedHotel is a merged hotel that could not be decoded into a Hotel, e.g. a latitude that is not a number
+type QuarantinedHotel struct {
+	HotelId string          `json:"hotel_id,omitempty"` // empty when the hotel has no readable id
+	Reason  string          `json:"reason"`
+	Hotel   json.RawMessage `json:"hotel"` // as merged from the suppliers
+}
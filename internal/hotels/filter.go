@@ -0,0 +1,96 @@
+package hotels
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortKey selects the order of search results, hotels are ordered by id unless another key is given
+type SortKey string
+
+const (
+	SortById          SortKey = "id"
+	SortByName        SortKey = "name"
+	SortByDestination SortKey = "destination"
+)
+
+// ParseSortKey validates a sort key, an empty string selects the default order by id
+func ParseSortKey(value string) (SortKey, error) {
+	switch key := SortKey(value); key {
+	case "":
+		return SortById, nil
+	case SortById, SortByName, SortByDestination:
+		return key, nil
+	default:
+		return "", fmt.Errorf("unknown sort %q, expected one of: %s, %s, %s", value, SortById, SortByName, SortByDestination)
+	}
+}
+
+// Query selects, orders and paginates hotels
+type Query struct {
+	Criteria []Criterion
+	Sort     SortKey
+	Offset   int
+	Limit    int // 0 returns all hotels after the offset
+}
+
+// Page is one page of search results
+type Page struct {
+	Hotels Hotels
+	Total  int // number of hotels matching the criteria across all pages
+}
+
+// Criterion narrows down the hotels returned by HotelStore.Search
+// a hotel matches a criterion when it matches any of its values, and a query when it matches all its criteria
+type Criterion interface {
+	// positions returns the positions in the snapshot of the hotels matching the criterion
+	positions(snap *snapshot) []int
+}
+
+// ByIds matches hotels with any of the given ids
+func ByIds(ids ...string) Criterion {
+	return idsCriterion(ids)
+}
+
+// ByDestinations matches hotels in any of the given destinations
+func ByDestinations(destinationIds ...int) Criterion {
+	return destinationsCriterion(destinationIds)
+}
+
+type idsCriterion []string
+
+func (c idsCriterion) positions(snap *snapshot) []int {
+	var positions []int
+	for _, id := range c {
+		if i, exists := snap.byId[strings.TrimSpace(id)]; exists {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+type destinationsCriterion []int
+
+func (c destinationsCriterion) positions(snap *snapshot) []int {
+	var positions []int
+	for _, destinationId := range c {
+		positions = append(positions, snap.byDestination[destinationId]...)
+	}
+	return positions
+}
+
+// intersect returns the positions present in both a and b
+func intersect(a, b []int) []int {
+	inB := make(map[int]bool, len(b))
+	for _, i := range b {
+		inB[i] = true
+	}
+
+	var result []int
+	for _, i := range a {
+		if inB[i] {
+			result = append(result, i)
+		}
+	}
+	return result
+}
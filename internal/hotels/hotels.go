@@ -14,11 +14,21 @@ type Hotel struct {
 }
 
 type Location struct {
-	Lat     float64 `json:"lat,omitempty"`
-	Lng     float64 `json:"lng,omitempty"`
-	Address string  `json:"address,omitempty"`
-	City    string  `json:"city,omitempty"`
-	Country string  `json:"country,omitempty"`
+	Lat          float64       `json:"lat,omitempty"`
+	Lng          float64       `json:"lng,omitempty"`
+	Address      string        `json:"address,omitempty"`
+	AddressParts *AddressParts `json:"address_parts,omitempty"` // set when mapped with merge_address:parts
+	City         string        `json:"city,omitempty"`
+	Country      string        `json:"country,omitempty"`
+}
+
+// AddressParts is the address split into its components
+type AddressParts struct {
+	Street     string `json:"street,omitempty"`
+	Building   string `json:"building,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	City       string `json:"city,omitempty"`
+	Country    string `json:"country,omitempty"`
 }
 
 type Amenities struct {
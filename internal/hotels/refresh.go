@@ -0,0 +1,80 @@
+package hotels
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
+)
+
+// RefreshConfig controls how often supplier data is fetched again after startup
+type RefreshConfig struct {
+	Interval   Duration `json:"interval,omitempty"`    // time between refreshes, refreshing is disabled when unset
+	Jitter     Duration `json:"jitter,omitempty"`      // random amount added to or removed from every interval
+	MinSpacing Duration `json:"min_spacing,omitempty"` // lower bound between the end of a refresh and the start of the next one
+
+	// MaxStaleness bounds how long the last payload of a failed supplier stands in for it, unset keeps it until the supplier recovers
+	MaxStaleness Duration `json:"max_staleness,omitempty"`
+}
+
+// Refresher periodically re-runs FetchAndNormalize and swaps successful results into the store
+// a failed refresh keeps the last known good hotels, only its report is recorded,
+// a supplier failing in an otherwise successful refresh is replaced by its last payload, see RefreshConfig.MaxStaleness
+type Refresher struct {
+	store    *HotelStore
+	engine   *mapper.MappingEngine
+	config   *Config
+	payloads map[string]supplierPayload // key: supplier name, from the last successful refresh
+}
+
+func NewRefresher(store *HotelStore, engine *mapper.MappingEngine, config *Config) *Refresher {
+	return &Refresher{
+		store:  store,
+		engine: engine,
+		config: config,
+	}
+}
+
+// Run refreshes the store on schedule until ctx is cancelled
+func (r *Refresher) Run(ctx context.Context) {
+	if r.config.Refresh.Interval <= 0 {
+		return
+	}
+
+	for {
+		if err := sleep(ctx, r.nextDelay()); err != nil {
+			return
+		}
+		r.Refresh(ctx)
+	}
+}
+
+// Refresh fetches all suppliers once and updates the store, it reports whether the hotels were replaced
+func (r *Refresher) Refresh(ctx context.Context) bool {
+	dataset, report, err := fetchAndNormalize(ctx, r.engine, r.config, r.payloads)
+	report.carryOver(r.store.Report())
+	report.Log()
+	r.store.SetReport(report)
+
+	if err != nil {
+		log.Printf("refresh failed, keeping previous data: %v", err)
+		return false
+	}
+
+	r.payloads = dataset.payloads
+	r.store.SetDataset(dataset)
+	return true
+}
+
+// nextDelay returns the wait before the next refresh: the interval plus or minus a random jitter,
+// but never less than the minimum spacing
+func (r *Refresher) nextDelay() time.Duration {
+	refresh := r.config.Refresh
+	delay := time.Duration(refresh.Interval)
+	if refresh.Jitter > 0 {
+		delay += time.Duration((2*rand.Float64() - 1) * float64(refresh.Jitter))
+	}
+	return max(delay, time.Duration(refresh.MinSpacing))
+}
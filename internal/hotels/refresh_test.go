@@ -0,0 +1,124 @@
+package hotels_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ptrciafae/hotels-merge/internal/hotels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefresher_KeepsLastKnownGoodDataOnFailure(t *testing.T) {
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `[{"id": "123", "name": "Hotel A"}]`)
+	}))
+	defer srv.Close()
+
+	config := loadTestConfig(t, srv.URL, `{}`)
+	store := hotels.NewHotelStore()
+	refresher := hotels.NewRefresher(store, newTestEngine(t), config)
+
+	require.True(t, refresher.Refresh(context.Background()))
+	require.Len(t, store.GetAll(), 1)
+	lastSuccess := store.Report().Suppliers[0].LastSuccessAt
+	require.NotNil(t, lastSuccess)
+
+	failing.Store(true)
+	assert.False(t, refresher.Refresh(context.Background()))
+
+	// hotels are kept while the report reflects the failed run
+	assert.Len(t, store.GetAll(), 1)
+	report := store.Report()
+	assert.Equal(t, hotels.SupplierFailed, report.Suppliers[0].Status)
+	assert.Equal(t, lastSuccess, report.Suppliers[0].LastSuccessAt)
+}
+
+func TestRefresher_ReusesThePayloadOfAFailedSupplier(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxStaleness string
+		served       bool // whether the hotel of the failed supplier is still served
+	}{
+		{name: "without max staleness", maxStaleness: `"0s"`, served: true},
+		{name: "past max staleness", maxStaleness: `"1ns"`, served: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upName atomic.Value
+			upName.Store("Hotel A")
+			up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `[{"id": "123", "name": %q}]`, upName.Load())
+			}))
+			defer up.Close()
+			var failing atomic.Bool
+			down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if failing.Load() {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				fmt.Fprint(w, `[{"id": "456", "name": "Hotel B"}]`)
+			}))
+			defer down.Close()
+
+			config, err := hotels.LoadConfig([]byte(fmt.Sprintf(`{
+				"refresh": {"max_staleness": %s},
+				"suppliers": [
+					{"name": "flaky", "url": %q, "enabled": true},
+					{"name": "down", "url": %q, "enabled": true}
+				]
+			}`, tt.maxStaleness, up.URL, down.URL)))
+			require.NoError(t, err)
+			store := hotels.NewHotelStore()
+			refresher := hotels.NewRefresher(store, newTestEngine(t), config)
+
+			require.True(t, refresher.Refresh(context.Background()))
+			require.Len(t, store.GetAll(), 2)
+
+			failing.Store(true)
+			upName.Store("Hotel A Renamed")
+			require.True(t, refresher.Refresh(context.Background()))
+
+			// the suppliers that are up still update the served hotels
+			hotel, found := store.Get("123")
+			require.True(t, found)
+			assert.Equal(t, "Hotel A Renamed", hotel.Name)
+
+			_, found = store.Get("456")
+			assert.Equal(t, tt.served, found)
+
+			report := store.Report()
+			assert.Equal(t, []string{"down"}, report.FailedSuppliers())
+			assert.NotNil(t, report.Suppliers[1].LastSuccessAt)
+			assert.Equal(t, tt.served, report.Suppliers[1].StaleSince != nil)
+		})
+	}
+}
+
+func TestRefresher_RunStopsWhenCancelled(t *testing.T) {
+	config, err := hotels.LoadConfig([]byte(`{
+		"refresh": {"interval": "1h"},
+		"suppliers": [{"name": "flaky", "url": "http://unused", "enabled": true}]
+	}`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hotels.NewRefresher(hotels.NewHotelStore(), newTestEngine(t), config).Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	<-done
+}
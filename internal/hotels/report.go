@@ -0,0 +1,135 @@
+package hotels
+
+import (
+	"log"
+	"time"
+
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
+)
+
+// SupplierStatus is the outcome of ingesting a single supplier
+type SupplierStatus string
+
+const (
+	SupplierOK       SupplierStatus = "ok"
+	SupplierFailed   SupplierStatus = "failed"
+	SupplierDisabled SupplierStatus = "disabled"
+)
+
+// IngestionReport describes one run of FetchAndNormalize
+type IngestionReport struct {
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Hotels     int              `json:"hotels"` // merged hotels produced by the run
+	Suppliers  []SupplierReport `json:"suppliers"`
+	Error      string           `json:"error,omitempty"` // set when the run produced no data at all
+
+	CoercionFailures []mapper.CoercionFailure `json:"coercion_failures,omitempty"` // supplier values of the wrong type, left out of the hotels
+	Quarantined      []QuarantinedHotel       `json:"quarantined,omitempty"`       // hotels left out of the run because they could not be decoded
+}
+
+// SupplierReport describes how a single supplier was fetched during an ingestion run
+type SupplierReport struct {
+	Name       string         `json:"name"`
+	Status     SupplierStatus `json:"status"`
+	HTTPStatus int            `json:"http_status,omitempty"` // status of the last attempt, 0 if no response was received
+	Attempts   int            `json:"attempts"`
+	Latency    Duration       `json:"latency"` // total time spent fetching, including retries
+	Bytes      int            `json:"bytes"`
+	Hotels     int            `json:"hotels"` // hotels in the supplier response before merging
+	Error      string         `json:"error,omitempty"`
+
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"` // last time the supplier was fetched successfully, also in previous runs
+	StaleSince    *time.Time `json:"stale_since,omitempty"`     // set when a failed supplier's hotels come from the payload fetched at this time
+}
+
+// FailedSuppliers returns the names of the enabled suppliers whose data is missing from the run
+func (r *IngestionReport) FailedSuppliers() []string {
+	var failed []string
+	for _, supplier := range r.Suppliers {
+		if supplier.Status == SupplierFailed {
+			failed = append(failed, supplier.Name)
+		}
+	}
+	return failed
+}
+
+// carryOver copies the last successful fetch time of suppliers that failed in this run from the previous report
+func (r *IngestionReport) carryOver(previous *IngestionReport) {
+	if previous == nil {
+		return
+	}
+
+	lastSuccess := make(map[string]*time.Time)
+	for _, supplier := range previous.Suppliers {
+		lastSuccess[supplier.Name] = supplier.LastSuccessAt
+	}
+
+	for i, supplier := range r.Suppliers {
+		if supplier.LastSuccessAt == nil {
+			r.Suppliers[i].LastSuccessAt = lastSuccess[supplier.Name]
+		}
+	}
+}
+
+// Log writes a one-line summary of the run followed by one line per supplier
+func (r *IngestionReport) Log() {
+	if failed := r.FailedSuppliers(); len(failed) > 0 {
+		log.Printf("ingestion finished in %s with %d hotels, missing suppliers: %v", r.FinishedAt.Sub(r.StartedAt), r.Hotels, failed)
+	} else {
+		log.Printf("ingestion finished in %s with %d hotels", r.FinishedAt.Sub(r.StartedAt), r.Hotels)
+	}
+	if r.Error != "" {
+		log.Printf("ingestion error: %s", r.Error)
+	}
+	for _, f := range r.CoercionFailures {
+		log.Printf("  hotel %s: %s from %s left out, expected %s: %s", f.HotelId, f.Field, f.Supplier, f.Type, f.Reason)
+	}
+	for _, q := range r.Quarantined {
+		log.Printf("  hotel %s: quarantined: %s", q.HotelId, q.Reason)
+	}
+
+	for _, s := range r.Suppliers {
+		switch s.Status {
+		case SupplierFailed:
+			log.Printf("  supplier %s: %s after %d attempt(s) in %s (http %d): %s", s.Name, s.Status, s.Attempts, time.Duration(s.Latency), s.HTTPStatus, s.Error)
+			if s.StaleSince != nil {
+				log.Printf("  supplier %s: serving the payload fetched at %s", s.Name, s.StaleSince.Format(time.RFC3339))
+			} else if s.LastSuccessAt != nil {
+				log.Printf("  supplier %s: missing since %s", s.Name, s.LastSuccessAt.Format(time.RFC3339))
+			}
+		case SupplierDisabled:
+			log.Printf("  supplier %s: %s", s.Name, s.Status)
+		default:
+			log.Printf("  supplier %s: %s, %d hotels, %d bytes in %s (%d attempt(s))", s.Name, s.Status, s.Hotels, s.Bytes, time.Duration(s.Latency), s.Attempts)
+		}
+	}
+}
+
+// ConflictReport lists the fields the suppliers disagree on, served by /admin/conflicts and printed by the conflicts command
+type ConflictReport struct {
+	Total     int               `json:"total"`
+	Conflicts []mapper.Conflict `json:"conflicts"`
+}
+
+// NewConflictReport counts the conflicts, an empty report lists no conflicts instead of null
+func NewConflictReport(conflicts []mapper.Conflict) ConflictReport {
+	if conflicts == nil {
+		conflicts = []mapper.Conflict{}
+	}
+	return ConflictReport{Total: len(conflicts), Conflicts: conflicts}
+}
+
+// UnmatchedReport lists the supplier values missing from the vocabularies, printed by the unmatched command
+type UnmatchedReport struct {
+	Total     int                    `json:"total"`
+	Unmatched []mapper.UnmatchedTerm `json:"unmatched"`
+}
+
+// NewUnmatchedReport counts the unmatched values, an empty report lists no values instead of null
+func NewUnmatchedReport(unmatched []mapper.UnmatchedTerm) UnmatchedReport {
+	if unmatched == nil {
+		unmatched = []mapper.UnmatchedTerm{}
+	}
+	return UnmatchedReport{Total: len(unmatched), Unmatched: unmatched}
+}
@@ -0,0 +1,96 @@
+package hotels
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultBackoffBase = 200 * time.Millisecond
+	defaultBackoffCap  = 5 * time.Second
+)
+
+// defaultRetryOn lists the statuses retried when a supplier does not configure its own
+var defaultRetryOn = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy controls how a failed supplier fetch is retried
+// transport errors are always retried, HTTP responses only when their status is in RetryOn
+type RetryPolicy struct {
+	MaxAttempts int      `json:"max_attempts,omitempty"` // total attempts including the first one, defaults to 1 (no retry)
+	BackoffBase Duration `json:"backoff_base,omitempty"` // wait before the first retry, doubled on every further retry
+	BackoffCap  Duration `json:"backoff_cap,omitempty"`  // upper bound of the exponential backoff
+	Jitter      float64  `json:"jitter,omitempty"`       // fraction (0..1) of each wait that is randomised
+	RetryOn     []int    `json:"retry_on,omitempty"`     // HTTP statuses worth retrying
+}
+
+// withDefaults fills in unset fields
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+	if p.BackoffBase <= 0 {
+		p.BackoffBase = Duration(defaultBackoffBase)
+	}
+	if p.BackoffCap <= 0 {
+		p.BackoffCap = Duration(defaultBackoffCap)
+	}
+	if p.RetryOn == nil {
+		p.RetryOn = slices.Clone(defaultRetryOn)
+	}
+	return p
+}
+
+// shouldRetryStatus reports whether a response with the given status is worth retrying
+func (p RetryPolicy) shouldRetryStatus(status int) bool {
+	return slices.Contains(p.RetryOn, status)
+}
+
+// backoff returns the wait before the given retry (1 for the first retry)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := time.Duration(p.BackoffBase)
+	for i := 1; i < retry && wait < time.Duration(p.BackoffCap); i++ {
+		wait *= 2
+	}
+	wait = min(wait, time.Duration(p.BackoffCap))
+
+	if p.Jitter > 0 {
+		wait -= time.Duration(p.Jitter * rand.Float64() * float64(wait))
+	}
+	return wait
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done, whichever comes first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -2,46 +2,230 @@ package hotels
 
 import (
 	"slices"
-	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
 )
 
+// HotelStore holds the hotels served by the API
+// readers load an immutable snapshot, Set builds a new snapshot and swaps it in atomically,
+// so reads never block and never observe a partially refreshed list
 type HotelStore struct {
-	hotels Hotels
+	snapshot atomic.Pointer[snapshot]
+	report   atomic.Pointer[IngestionReport]
+}
+
+// snapshot is an immutable view of the hotels, it must not be modified once stored
+// hotels are kept sorted by id, indexes hold positions in hotels
+type snapshot struct {
+	hotels        Hotels
+	byId          map[string]int
+	byDestination map[int][]int
+	ranks         map[SortKey][]int // key: sort key, value: rank of the hotel at each position
+	provenance    map[string]mapper.HotelProvenance
+	conflicts     []mapper.Conflict
+	version       uint64
+	updatedAt     time.Time
+}
+
+// newSnapshot sorts a copy of hotels by id and indexes it by id and destination id
+func newSnapshot(hotels Hotels) *snapshot {
+	hotels = slices.Clone(hotels)
+	slices.SortStableFunc(hotels, func(a, b Hotel) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	snap := &snapshot{
+		hotels:        hotels,
+		byId:          make(map[string]int, len(hotels)),
+		byDestination: make(map[int][]int),
+		ranks: map[SortKey][]int{
+			SortByName: rankPositions(hotels, func(a, b Hotel) int {
+				return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+			}),
+			SortByDestination: rankPositions(hotels, func(a, b Hotel) int {
+				return a.DestinationId - b.DestinationId
+			}),
+		},
+	}
+
+	for i, h := range hotels {
+		id := strings.TrimSpace(h.Id)
+		if _, exists := snap.byId[id]; !exists { // keep the first hotel if ids are duplicated
+			snap.byId[id] = i
+		}
+		snap.byDestination[h.DestinationId] = append(snap.byDestination[h.DestinationId], i)
+	}
+
+	return snap
+}
+
+// rankPositions returns the rank of every position when hotels are sorted with cmp,
+// ties keep the id order of the snapshot
+func rankPositions(hotels Hotels, cmp func(a, b Hotel) int) []int {
+	order := make([]int, len(hotels))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp(hotels[a], hotels[b])
+	})
+
+	ranks := make([]int, len(hotels))
+	for rank, i := range order {
+		ranks[i] = rank
+	}
+	return ranks
+}
+
+// sortPositions removes duplicated positions and orders them by the given sort key
+func (snap *snapshot) sortPositions(positions []int, sortKey SortKey) []int {
+	slices.Sort(positions) // id order
+	positions = slices.Compact(positions)
+
+	if ranks, ok := snap.ranks[sortKey]; ok {
+		slices.SortFunc(positions, func(a, b int) int {
+			return ranks[a] - ranks[b]
+		})
+	}
+	return positions
+}
+
+// collect returns the hotels at the given positions, in that order
+func (snap *snapshot) collect(positions []int) Hotels {
+	result := make(Hotels, 0, len(positions))
+	for _, i := range positions {
+		result = append(result, snap.hotels[i])
+	}
+	return result
+}
+
+// SnapshotInfo identifies the snapshot currently served by the store
+type SnapshotInfo struct {
+	Version   uint64    `json:"version"` // incremented on every Set, 0 before the first one
+	UpdatedAt time.Time `json:"updated_at"`
+	Hotels    int       `json:"hotels"`
 }
 
 func NewHotelStore() *HotelStore {
-	return &HotelStore{}
+	s := &HotelStore{}
+	s.snapshot.Store(newSnapshot(nil))
+	return s
 }
 
+// Set replaces the served hotels
 func (s *HotelStore) Set(hotels Hotels) {
-	s.hotels = hotels
+	s.SetDataset(&Dataset{Hotels: hotels})
+}
+
+// SetDataset replaces the served hotels and what was recorded while merging them
+func (s *HotelStore) SetDataset(dataset *Dataset) {
+	next := newSnapshot(dataset.Hotels)
+	next.provenance = dataset.Provenance
+	next.conflicts = dataset.Conflicts
+	next.updatedAt = time.Now()
+
+	// next is not visible to readers until the swap succeeds, so it can be adjusted between attempts
+	for {
+		current := s.snapshot.Load()
+		next.version = current.version + 1
+		if s.snapshot.CompareAndSwap(current, next) {
+			return
+		}
+	}
+}
+
+// Snapshot describes the currently served snapshot
+func (s *HotelStore) Snapshot() SnapshotInfo {
+	current := s.snapshot.Load()
+	return SnapshotInfo{
+		Version:   current.version,
+		UpdatedAt: current.updatedAt,
+		Hotels:    len(current.hotels),
+	}
+}
+
+// SetReport records the report of the latest ingestion run
+func (s *HotelStore) SetReport(report *IngestionReport) {
+	s.report.Store(report)
+}
+
+// Report returns the report of the latest ingestion run, nil if none ran yet
+func (s *HotelStore) Report() *IngestionReport {
+	return s.report.Load()
 }
 
 func (s *HotelStore) GetAll() Hotels {
-	return s.hotels
+	return s.snapshot.Load().hotels
 }
 
-func (s *HotelStore) FilterByIds(ids string) Hotels {
-	idsArr := strings.Split(ids, ",")
-	var result Hotels
+// Get returns the hotel with the given id
+func (s *HotelStore) Get(id string) (Hotel, bool) {
+	snap := s.snapshot.Load()
+	i, exists := snap.byId[strings.TrimSpace(id)]
+	if !exists {
+		return Hotel{}, false
+	}
+	return snap.hotels[i], true
+}
 
-	for _, h := range s.hotels {
-		if slices.Contains(idsArr, strings.TrimSpace(h.Id)) {
-			result = append(result, h)
-		}
+// Provenance returns where the fields of the hotel with the given id came from,
+// false if the hotel does not exist or provenance is not recorded
+func (s *HotelStore) Provenance(id string) (mapper.HotelProvenance, bool) {
+	snap := s.snapshot.Load()
+	i, exists := snap.byId[strings.TrimSpace(id)]
+	if !exists {
+		return nil, false
 	}
+	provenance, recorded := snap.provenance[snap.hotels[i].Id]
+	return provenance, recorded
+}
 
-	return result
+// Conflicts returns the fields the suppliers disagree on, nil if conflict detection is disabled
+func (s *HotelStore) Conflicts() []mapper.Conflict {
+	return s.snapshot.Load().conflicts
+}
+
+// Filter returns the hotels matching every criterion sorted by id, all hotels when no criteria are given
+func (s *HotelStore) Filter(criteria ...Criterion) Hotels {
+	return s.Search(Query{Criteria: criteria}).Hotels
 }
 
-func (s *HotelStore) FilterByDestinations(destinationIds string) Hotels {
-	destinationIdsArr := strings.Split(destinationIds, ",")
-	var result Hotels
-	for _, h := range s.hotels {
-		if slices.Contains(destinationIdsArr, strings.TrimSpace(strconv.Itoa(h.DestinationId))) {
-			result = append(result, h)
+// Search returns one page of the hotels matching every criterion of the query
+func (s *HotelStore) Search(query Query) Page {
+	snap := s.snapshot.Load()
+	positions := snap.match(query.Criteria)
+	positions = snap.sortPositions(positions, query.Sort)
+
+	page := Page{Total: len(positions)}
+	start := min(max(query.Offset, 0), len(positions))
+	end := len(positions)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, end)
+	}
+	page.Hotels = snap.collect(positions[start:end])
+
+	return page
+}
+
+// match returns the positions of the hotels matching every criterion
+func (snap *snapshot) match(criteria []Criterion) []int {
+	if len(criteria) == 0 {
+		positions := make([]int, len(snap.hotels))
+		for i := range positions {
+			positions[i] = i
 		}
+		return positions
 	}
-	return result
+
+	positions := criteria[0].positions(snap)
+	for _, criterion := range criteria[1:] {
+		if len(positions) == 0 {
+			break
+		}
+		positions = intersect(positions, criterion.positions(snap))
+	}
+	return positions
 }
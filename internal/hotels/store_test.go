@@ -0,0 +1,218 @@
+package hotels_test
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ptrciafae/hotels-merge/internal/hotels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeHotels returns n hotels spread over destinations of 10 hotels each
+func makeHotels(n int) hotels.Hotels {
+	result := make(hotels.Hotels, n)
+	for i := range result {
+		result[i] = hotels.Hotel{
+			Id:            fmt.Sprintf("h%d", i),
+			DestinationId: i / 10,
+			Name:          fmt.Sprintf("Hotel %d", i),
+		}
+	}
+	return result
+}
+
+func TestHotelStore_SnapshotVersion(t *testing.T) {
+	store := hotels.NewHotelStore()
+	assert.Equal(t, uint64(0), store.Snapshot().Version)
+	assert.True(t, store.Snapshot().UpdatedAt.IsZero())
+
+	store.Set(makeHotels(3))
+	first := store.Snapshot()
+	assert.Equal(t, uint64(1), first.Version)
+	assert.Equal(t, 3, first.Hotels)
+	assert.False(t, first.UpdatedAt.IsZero())
+
+	store.Set(makeHotels(5))
+	second := store.Snapshot()
+	assert.Equal(t, uint64(2), second.Version)
+	assert.Equal(t, 5, second.Hotels)
+	assert.False(t, second.UpdatedAt.Before(first.UpdatedAt))
+}
+
+// run with -race: readers hammer the store while it is repeatedly replaced
+func TestHotelStore_ConcurrentReadsAndWrites(t *testing.T) {
+	store := hotels.NewHotelStore()
+	store.Set(makeHotels(100))
+
+	const writes = 200
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range writes {
+			store.Set(makeHotels(100 + i%10))
+			store.SetReport(&hotels.IngestionReport{Hotels: i})
+		}
+	}()
+
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range writes {
+				all := store.GetAll()
+				assert.GreaterOrEqual(t, len(all), 100)
+				assert.Len(t, store.Filter(hotels.ByIds("h1", "h50")), 2)
+				assert.Len(t, store.Filter(hotels.ByDestinations(3)), 10)
+				store.Snapshot()
+				store.Report()
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, uint64(writes+1), store.Snapshot().Version)
+}
+
+func TestHotelStore_FilterSortsById(t *testing.T) {
+	store := hotels.NewHotelStore()
+	store.Set(makeHotels(30))
+
+	result := store.Filter(hotels.ByIds("h3", " h25", "h3", "missing"))
+	require.Len(t, result, 2)
+	assert.Equal(t, "h25", result[0].Id)
+	assert.Equal(t, "h3", result[1].Id)
+
+	result = store.Filter(hotels.ByDestinations(2, 0))
+	require.Len(t, result, 20)
+	assert.Equal(t, "h0", result[0].Id)
+	assert.Equal(t, "h9", result[19].Id)
+}
+
+func TestHotelStore_FilterCombinesCriteria(t *testing.T) {
+	store := hotels.NewHotelStore()
+	store.Set(makeHotels(30))
+
+	// criteria are ANDed, values within a criterion are ORed
+	result := store.Filter(hotels.ByIds("h3", "h15", "h25"), hotels.ByDestinations(0, 2))
+	require.Len(t, result, 2)
+	assert.Equal(t, "h25", result[0].Id)
+	assert.Equal(t, "h3", result[1].Id)
+
+	assert.Empty(t, store.Filter(hotels.ByIds("h3"), hotels.ByDestinations(1)))
+	assert.Len(t, store.Filter(), 30)
+}
+
+func TestHotelStore_SearchSortsAndPaginates(t *testing.T) {
+	store := hotels.NewHotelStore()
+	store.Set(hotels.Hotels{
+		{Id: "c", DestinationId: 1, Name: "alpha"},
+		{Id: "a", DestinationId: 3, Name: "Charlie"},
+		{Id: "d", DestinationId: 2, Name: "bravo"},
+		{Id: "b", DestinationId: 1, Name: "Delta"},
+	})
+
+	ids := func(page hotels.Page) []string {
+		var result []string
+		for _, h := range page.Hotels {
+			result = append(result, h.Id)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids(store.Search(hotels.Query{})))
+	assert.Equal(t, []string{"c", "d", "a", "b"}, ids(store.Search(hotels.Query{Sort: hotels.SortByName})))
+	assert.Equal(t, []string{"b", "c", "d", "a"}, ids(store.Search(hotels.Query{Sort: hotels.SortByDestination})))
+
+	page := store.Search(hotels.Query{Sort: hotels.SortByName, Offset: 1, Limit: 2})
+	assert.Equal(t, 4, page.Total)
+	assert.Equal(t, []string{"d", "a"}, ids(page))
+
+	page = store.Search(hotels.Query{Criteria: []hotels.Criterion{hotels.ByDestinations(1)}, Offset: 5, Limit: 2})
+	assert.Equal(t, 2, page.Total)
+	assert.Empty(t, page.Hotels)
+}
+
+// linearFilterByIds and linearFilterByDestinations are the scans the store used before it was indexed,
+// kept to compare against in benchmarks
+func linearFilterByIds(all hotels.Hotels, ids string) hotels.Hotels {
+	idsArr := strings.Split(ids, ",")
+	var result hotels.Hotels
+	for _, h := range all {
+		if slices.Contains(idsArr, strings.TrimSpace(h.Id)) {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+func linearFilterByDestinations(all hotels.Hotels, destinationIds string) hotels.Hotels {
+	destinationIdsArr := strings.Split(destinationIds, ",")
+	var result hotels.Hotels
+	for _, h := range all {
+		if slices.Contains(destinationIdsArr, strings.TrimSpace(strconv.Itoa(h.DestinationId))) {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+const (
+	benchmarkHotels         = 200_000
+	benchmarkIds            = "h17,h4242,h99999,h150000,h199999"
+	benchmarkDestinationIds = "1,420,9999,15000"
+)
+
+var (
+	benchmarkIdsCriterion          = hotels.ByIds("h17", "h4242", "h99999", "h150000", "h199999")
+	benchmarkDestinationsCriterion = hotels.ByDestinations(1, 420, 9999, 15000)
+)
+
+func BenchmarkFilterByIds_Linear(b *testing.B) {
+	all := makeHotels(benchmarkHotels)
+	b.ResetTimer()
+	for range b.N {
+		linearFilterByIds(all, benchmarkIds)
+	}
+}
+
+func BenchmarkFilterByIds_Indexed(b *testing.B) {
+	store := hotels.NewHotelStore()
+	store.Set(makeHotels(benchmarkHotels))
+	b.ResetTimer()
+	for range b.N {
+		store.Filter(benchmarkIdsCriterion)
+	}
+}
+
+func BenchmarkFilterByDestinations_Linear(b *testing.B) {
+	all := makeHotels(benchmarkHotels)
+	b.ResetTimer()
+	for range b.N {
+		linearFilterByDestinations(all, benchmarkDestinationIds)
+	}
+}
+
+func BenchmarkFilterByDestinations_Indexed(b *testing.B) {
+	store := hotels.NewHotelStore()
+	store.Set(makeHotels(benchmarkHotels))
+	b.ResetTimer()
+	for range b.N {
+		store.Filter(benchmarkDestinationsCriterion)
+	}
+}
+
+func BenchmarkHotelStore_Set(b *testing.B) {
+	all := makeHotels(benchmarkHotels)
+	store := hotels.NewHotelStore()
+	b.ResetTimer()
+	for range b.N {
+		store.Set(all)
+	}
+}
@@ -1,72 +1,291 @@
 package hotels
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/ptrciafae/hotels-merge/internal/mapper"
 )
 
-type Suppliers struct {
-	Name string
-	URL  string
-	Data json.RawMessage
+// httpClient is shared by all supplier fetches, deadlines are set per request through the context
+var httpClient = &http.Client{}
+
+// ErrNoSupplierData is returned when no enabled supplier could be fetched
+var ErrNoSupplierData = errors.New("no supplier data could be fetched")
+
+// ErrAllHotelsQuarantined is returned when none of the merged hotels could be decoded
+var ErrAllHotelsQuarantined = errors.New("every merged hotel was quarantined")
+
+// supplierResponse is the outcome of fetching a single supplier
+type supplierResponse struct {
+	body       []byte
+	httpStatus int // status of the last attempt, 0 if no response was received
+	attempts   int
+	latency    time.Duration
+	err        error
 }
 
-func GetSuppliers() []Suppliers {
-	return []Suppliers{
-		{Name: "acme", URL: "https://5f2be0b4ffc88500167b85a0.mockapi.io/suppliers/acme"},
-		{Name: "patagonia", URL: "https://5f2be0b4ffc88500167b85a0.mockapi.io/suppliers/patagonia"},
-		{Name: "paperflies", URL: "https://5f2be0b4ffc88500167b85a0.mockapi.io/suppliers/paperflies"},
-	}
+// supplierPayload is the last response of a supplier that could be read, kept to stand in for it while it fails
+type supplierPayload struct {
+	body      json.RawMessage
+	fetchedAt time.Time
 }
 
-func FetchAndNormalize(engine *mapper.MappingEngine) (Hotels, error) {
+// FetchAndNormalize fetches every enabled supplier and merges their hotels
+// the report is always returned, also when an error is, so callers can log what went wrong
+func FetchAndNormalize(ctx context.Context, engine *mapper.MappingEngine, config *Config) (*Dataset, *IngestionReport, error) {
+	return fetchAndNormalize(ctx, engine, config, nil)
+}
+
+// fetchAndNormalize is FetchAndNormalize where a failed supplier is replaced by its payload in previous, if any,
+// as long as at least one supplier was fetched and the payload is not older than the refresh max_staleness
+func fetchAndNormalize(ctx context.Context, engine *mapper.MappingEngine, config *Config, previous map[string]supplierPayload) (*Dataset, *IngestionReport, error) {
+	report := &IngestionReport{StartedAt: time.Now()}
+	defer func() { report.FinishedAt = time.Now() }()
+
+	suppliers := config.EnabledSuppliers()
+	fetched := fetchAllSuppliers(ctx, suppliers, config.MaxConcurrentFetches)
+
 	responses := map[string]json.RawMessage{} // key: supplier name, value: raw JSON data
-	for _, supplier := range GetSuppliers() {
-		body, err := fetchSupplierData(supplier.Name, supplier.URL)
-		if err != nil {
+	payloads := make(map[string]supplierPayload)
+	var failed []int // indexes in the supplier reports
+	for i, response := range fetched {
+		supplierReport := SupplierReport{
+			Name:       suppliers[i].Name,
+			Status:     SupplierOK,
+			HTTPStatus: response.httpStatus,
+			Attempts:   response.attempts,
+			Latency:    Duration(response.latency),
+			Bytes:      len(response.body),
+		}
+
+		var hotelItems []json.RawMessage
+		if response.err == nil {
+			if err := json.Unmarshal(response.body, &hotelItems); err != nil {
+				response.err = fmt.Errorf("response from %s is not a JSON array: %w", suppliers[i].Name, err)
+			}
+		}
+
+		if response.err != nil {
+			supplierReport.Status = SupplierFailed
+			supplierReport.Error = response.err.Error()
+			failed = append(failed, len(report.Suppliers))
+		} else {
+			fetchedAt := time.Now()
+			supplierReport.LastSuccessAt = &fetchedAt
+			supplierReport.Hotels = len(hotelItems)
+			responses[suppliers[i].Name] = response.body
+			payloads[suppliers[i].Name] = supplierPayload{body: response.body, fetchedAt: fetchedAt}
+		}
+		report.Suppliers = append(report.Suppliers, supplierReport)
+	}
+
+	for _, supplier := range config.Suppliers {
+		if !supplier.Enabled {
+			report.Suppliers = append(report.Suppliers, SupplierReport{Name: supplier.Name, Status: SupplierDisabled})
+		}
+	}
+
+	// abort instead of merging a partial result when the caller gave up
+	if err := ctx.Err(); err != nil {
+		err = fmt.Errorf("fetching suppliers cancelled: %w", err)
+		report.Error = err.Error()
+		return nil, report, err
+	}
+
+	if len(suppliers) > 0 && len(responses) == 0 {
+		report.Error = ErrNoSupplierData.Error()
+		return nil, report, ErrNoSupplierData
+	}
+
+	// a supplier that is down keeps contributing its last payload, so its hotels and fields are not lost
+	maxStaleness := time.Duration(config.Refresh.MaxStaleness)
+	for _, index := range failed {
+		supplierReport := &report.Suppliers[index]
+		payload, found := previous[supplierReport.Name]
+		if !found || maxStaleness > 0 && time.Since(payload.fetchedAt) > maxStaleness {
 			continue
 		}
-		responses[supplier.Name] = body
+		responses[supplierReport.Name] = payload.body
+		payloads[supplierReport.Name] = payload
+		supplierReport.StaleSince = &payload.fetchedAt
+	}
+
+	dataset, err := deduplicateHotels(responses, engine)
+	if err != nil {
+		report.Error = err.Error()
+		return nil, report, err
+	}
+	dataset.payloads = payloads
+	report.Hotels = len(dataset.Hotels)
+	report.CoercionFailures = dataset.CoercionFailures
+	report.Quarantined = dataset.Quarantined
+
+	// an empty result would replace the served hotels, which is only right when the suppliers sent none
+	if len(dataset.Hotels) == 0 && len(dataset.Quarantined) > 0 {
+		report.Error = ErrAllHotelsQuarantined.Error()
+		return nil, report, ErrAllHotelsQuarantined
+	}
+
+	return dataset, report, nil
+}
+
+// fetchAllSuppliers fetches suppliers concurrently with at most maxConcurrent requests in flight
+// results are returned in the same order as suppliers
+func fetchAllSuppliers(ctx context.Context, suppliers []Supplier, maxConcurrent int) []supplierResponse {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
 	}
+	maxConcurrent = min(maxConcurrent, len(suppliers))
 
-	return deduplicateHotels(responses, engine)
+	results := make([]supplierResponse, len(suppliers))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range maxConcurrent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = fetchSupplierData(ctx, suppliers[i])
+			}
+		}()
+	}
+
+	for i := range suppliers {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// fetchSupplierData fetches a supplier, retrying according to its retry policy
+// the supplier timeout bounds the whole fetch, attempts and backoff included
+func fetchSupplierData(ctx context.Context, supplier Supplier) (response supplierResponse) {
+	policy := supplier.Retry
+	start := time.Now()
+	defer func() { response.latency = time.Since(start) }()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(supplier.Timeout))
+	defer cancel()
+
+	// a request that cannot be built will not get better by retrying
+	req, err := http.NewRequestWithContext(ctx, supplier.Method, supplier.URL, nil)
+	if err != nil {
+		response.err = fmt.Errorf("error creating request to %s: %w", supplier.Name, err)
+		return response
+	}
+	for key, value := range supplier.Headers {
+		req.Header.Set(key, value)
+	}
+
+	for attempt := 1; ; attempt++ {
+		body, resp, err := fetchSupplierAttempt(supplier, req)
+		response.attempts = attempt
+		response.httpStatus = 0
+		if resp != nil {
+			response.httpStatus = resp.StatusCode
+		}
+		if err == nil {
+			response.body = body
+			return response
+		}
+
+		// transport errors are retried, HTTP errors only for the configured statuses
+		retryable := resp == nil || policy.shouldRetryStatus(resp.StatusCode)
+		if !retryable || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			response.err = err
+			return response
+		}
+
+		wait := policy.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				wait = min(retryAfter, time.Duration(policy.BackoffCap)) // a day long Retry-After must not stall the run
+			}
+		}
+		if sleepErr := sleep(ctx, wait); sleepErr != nil {
+			response.err = fmt.Errorf("gave up retrying %s: %w", supplier.Name, err)
+			return response
+		}
+	}
 }
 
-func fetchSupplierData(name, url string) ([]byte, error) {
-	resp, err := http.Get(url)
+// fetchSupplierAttempt makes a single request to a supplier
+// the response is returned alongside the error for non-200 statuses so the caller can decide to retry
+func fetchSupplierAttempt(supplier Supplier, req *http.Request) ([]byte, *http.Response, error) {
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error making GET request to %s: %w", name, err)
+		return nil, nil, fmt.Errorf("error making %s request to %s: %w", supplier.Method, supplier.Name, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body from %s: %w", name, err)
+		return nil, nil, fmt.Errorf("error reading response body from %s: %w", supplier.Name, err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch %s: %s", name, resp.Status)
+		return nil, resp, fmt.Errorf("failed to fetch %s: %s", supplier.Name, resp.Status)
 	}
 
-	return body, nil
+	return body, resp, nil
 }
 
-func deduplicateHotels(hotelsList map[string]json.RawMessage, engine *mapper.MappingEngine) (Hotels, error) {
-	normalizedData, err := engine.Transform(hotelsList)
+func deduplicateHotels(hotelsList map[string]json.RawMessage, engine *mapper.MappingEngine) (*Dataset, error) {
+	transformed, err := engine.TransformDetailed(hotelsList)
 	if err != nil {
 		return nil, fmt.Errorf("error transforming data: %w", err)
 	}
 
-	var hotels Hotels
-	if err := json.Unmarshal(normalizedData, &hotels); err != nil {
+	var records []json.RawMessage
+	if err := json.Unmarshal(transformed.Hotels, &records); err != nil {
 		return nil, fmt.Errorf("error unmarshaling normalized data: %w", err)
 	}
 
-	fmt.Printf("hotels: %+v\n", hotels)
+	dataset := &Dataset{Hotels: make(Hotels, 0, len(records)), Provenance: transformed.Provenance, Conflicts: transformed.Conflicts, Unmatched: transformed.Unmatched, CoercionFailures: transformed.CoercionFailures}
+	for _, record := range records {
+		hotel, err := decodeHotel(record)
+		if err != nil {
+			// one malformed hotel is left out instead of failing the whole run
+			dataset.Quarantined = append(dataset.Quarantined, QuarantinedHotel{HotelId: hotel.Id, Reason: err.Error(), Hotel: record})
+			delete(dataset.Provenance, hotel.Id)
+			continue
+		}
+		dataset.Hotels = append(dataset.Hotels, hotel)
+	}
+
+	if len(dataset.Quarantined) > 0 {
+		dataset.Conflicts = slices.DeleteFunc(dataset.Conflicts, func(c mapper.Conflict) bool {
+			return slices.ContainsFunc(dataset.Quarantined, func(q QuarantinedHotel) bool { return q.HotelId == c.HotelId })
+		})
+	}
+	return dataset, nil
+}
 
-	return hotels, nil
+// decodeHotel decodes a single merged hotel, the id is set even when the rest of the hotel cannot be decoded
+func decodeHotel(record json.RawMessage) (Hotel, error) {
+	var hotel Hotel
+	if err := json.Unmarshal(record, &hotel); err != nil {
+		var key struct {
+			Id interface{} `json:"id"`
+		}
+		if json.Unmarshal(record, &key) == nil && key.Id != nil {
+			hotel.Id = fmt.Sprint(key.Id)
+		}
+		return hotel, err
+	}
+	if hotel.Id == "" {
+		return hotel, errors.New("hotel has no id")
+	}
+	return hotel, nil
 }
@@ -0,0 +1,361 @@
+package hotels_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ptrciafae/hotels-merge/internal/hotels"
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMapping = `{
+	"id": {
+		"src::flaky": "id",
+		"src::down": "id"
+	},
+	"name": {
+		"src::flaky": "name",
+		"src::down": "name"
+	}
+}`
+
+// flakyServer fails the first `failures` requests with the given status before serving one hotel
+func flakyServer(t *testing.T, failures int, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(hits.Add(1)) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, `[{"id": "123", "name": "Hotel A"}]`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func loadTestConfig(t *testing.T, url string, retry string) *hotels.Config {
+	t.Helper()
+	config, err := hotels.LoadConfig([]byte(fmt.Sprintf(`{
+		"suppliers": [
+			{"name": "flaky", "url": %q, "timeout": "5s", "retry": %s, "enabled": true}
+		]
+	}`, url, retry)))
+	require.NoError(t, err)
+	return config
+}
+
+func newTestEngine(t *testing.T) *mapper.MappingEngine {
+	t.Helper()
+	engine, err := mapper.NewMappingEngine([]byte(testMapping))
+	require.NoError(t, err)
+	return engine
+}
+
+func TestFetchAndNormalize_RetriesUntilSuccess(t *testing.T) {
+	srv, hits := flakyServer(t, 2, http.StatusServiceUnavailable, nil)
+	config := loadTestConfig(t, srv.URL, `{"max_attempts": 3, "backoff_base": "1ms", "backoff_cap": "5ms", "jitter": 0.5}`)
+
+	result, _, err := hotels.FetchAndNormalize(context.Background(), newTestEngine(t), config)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), hits.Load())
+	require.Len(t, result.Hotels, 1)
+	assert.Equal(t, "Hotel A", result.Hotels[0].Name)
+}
+
+func TestFetchAndNormalize_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv, hits := flakyServer(t, 5, http.StatusBadGateway, nil)
+	config := loadTestConfig(t, srv.URL, `{"max_attempts": 3, "backoff_base": "1ms"}`)
+
+	_, report, err := hotels.FetchAndNormalize(context.Background(), newTestEngine(t), config)
+	require.ErrorIs(t, err, hotels.ErrNoSupplierData)
+
+	assert.Equal(t, int32(3), hits.Load())
+	require.Len(t, report.Suppliers, 1)
+	assert.Equal(t, hotels.SupplierFailed, report.Suppliers[0].Status)
+	assert.Equal(t, http.StatusBadGateway, report.Suppliers[0].HTTPStatus)
+	assert.Equal(t, 3, report.Suppliers[0].Attempts)
+}
+
+func TestFetchAndNormalize_DoesNotRetryUnlistedStatus(t *testing.T) {
+	srv, hits := flakyServer(t, 1, http.StatusNotFound, nil)
+	config := loadTestConfig(t, srv.URL, `{"max_attempts": 3, "backoff_base": "1ms"}`)
+
+	_, _, err := hotels.FetchAndNormalize(context.Background(), newTestEngine(t), config)
+	require.ErrorIs(t, err, hotels.ErrNoSupplierData)
+
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestFetchAndNormalize_HonorsRetryAfter(t *testing.T) {
+	srv, hits := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+	config := loadTestConfig(t, srv.URL, `{"max_attempts": 2, "backoff_base": "1ms"}`)
+
+	start := time.Now()
+	result, _, err := hotels.FetchAndNormalize(context.Background(), newTestEngine(t), config)
+	require.NoError(t, err)
+
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), hits.Load())
+	assert.Len(t, result.Hotels, 1)
+}
+
+func TestFetchAndNormalize_CapsRetryAfter(t *testing.T) {
+	srv, hits := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"86400"}})
+	config := loadTestConfig(t, srv.URL, `{"max_attempts": 2, "backoff_base": "1ms", "backoff_cap": "10ms"}`)
+
+	start := time.Now()
+	result, _, err := hotels.FetchAndNormalize(context.Background(), newTestEngine(t), config)
+	require.NoError(t, err)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), hits.Load())
+	assert.Len(t, result.Hotels, 1)
+}
+
+func TestFetchAndNormalize_DoesNotRetryInvalidRequest(t *testing.T) {
+	config := loadTestConfig(t, "http://[::1", `{"max_attempts": 3, "backoff_base": "1s"}`)
+
+	start := time.Now()
+	_, report, err := hotels.FetchAndNormalize(context.Background(), newTestEngine(t), config)
+	require.ErrorIs(t, err, hotels.ErrNoSupplierData)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 0, report.Suppliers[0].Attempts)
+	assert.Contains(t, report.Suppliers[0].Error, "error creating request to flaky")
+}
+
+func TestFetchAndNormalize_StopsRetryingWhenCancelled(t *testing.T) {
+	srv, hits := flakyServer(t, 5, http.StatusServiceUnavailable, nil)
+	config := loadTestConfig(t, srv.URL, `{"max_attempts": 5, "backoff_base": "1s"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, _, err := hotels.FetchAndNormalize(ctx, newTestEngine(t), config)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestFetchAndNormalize_TimeoutBoundsAllAttempts(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	config, err := hotels.LoadConfig([]byte(fmt.Sprintf(`{
+		"suppliers": [
+			{"name": "flaky", "url": %q, "timeout": "100ms", "retry": {"max_attempts": 5, "backoff_base": "1ms"}, "enabled": true}
+		]
+	}`, srv.URL)))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, report, err := hotels.FetchAndNormalize(context.Background(), newTestEngine(t), config)
+	require.ErrorIs(t, err, hotels.ErrNoSupplierData)
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), hits.Load())
+	assert.Contains(t, report.Suppliers[0].Error, "deadline exceeded")
+}
+
+func TestFetchAndNormalize_BoundsConcurrentFetches(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, `[{"id": "123"}]`)
+	}))
+	t.Cleanup(srv.Close)
+
+	var suppliers, ids []string
+	for i := range 6 {
+		suppliers = append(suppliers, fmt.Sprintf(`{"name": "s%d", "url": %q, "enabled": true}`, i, srv.URL))
+		ids = append(ids, fmt.Sprintf(`"src::s%d": "id"`, i))
+	}
+	config, err := hotels.LoadConfig([]byte(`{"max_concurrent_fetches": 2, "suppliers": [` + strings.Join(suppliers, ",") + `]}`))
+	require.NoError(t, err)
+	engine, err := mapper.NewMappingEngine([]byte(`{"id": {` + strings.Join(ids, ",") + `}}`))
+	require.NoError(t, err)
+
+	result, report, err := hotels.FetchAndNormalize(context.Background(), engine, config)
+	require.NoError(t, err)
+	assert.Len(t, result.Hotels, 1)
+	assert.Empty(t, report.FailedSuppliers())
+	assert.Equal(t, int32(2), maxInFlight.Load())
+}
+
+func TestFetchAndNormalize_ReportsPartialFailure(t *testing.T) {
+	up, _ := flakyServer(t, 0, http.StatusOK, nil)
+	down, _ := flakyServer(t, 1, http.StatusInternalServerError, nil)
+
+	config, err := hotels.LoadConfig([]byte(fmt.Sprintf(`{
+		"suppliers": [
+			{"name": "flaky", "url": %q, "enabled": true},
+			{"name": "down", "url": %q, "enabled": true},
+			{"name": "paused", "url": "http://unused", "enabled": false}
+		]
+	}`, up.URL, down.URL)))
+	require.NoError(t, err)
+
+	result, report, err := hotels.FetchAndNormalize(context.Background(), newTestEngine(t), config)
+	require.NoError(t, err)
+	require.Len(t, result.Hotels, 1)
+
+	assert.Equal(t, 1, report.Hotels)
+	assert.Equal(t, []string{"down"}, report.FailedSuppliers())
+	require.Len(t, report.Suppliers, 3)
+
+	require.NotNil(t, report.Suppliers[0].LastSuccessAt)
+	assert.Equal(t, hotels.SupplierReport{
+		Name:          "flaky",
+		Status:        hotels.SupplierOK,
+		HTTPStatus:    http.StatusOK,
+		Attempts:      1,
+		Latency:       report.Suppliers[0].Latency,
+		Bytes:         len(`[{"id": "123", "name": "Hotel A"}]`),
+		Hotels:        1,
+		LastSuccessAt: report.Suppliers[0].LastSuccessAt,
+	}, report.Suppliers[0])
+
+	assert.Equal(t, hotels.SupplierFailed, report.Suppliers[1].Status)
+	assert.Equal(t, http.StatusInternalServerError, report.Suppliers[1].HTTPStatus)
+	assert.Contains(t, report.Suppliers[1].Error, "500")
+	assert.Nil(t, report.Suppliers[1].LastSuccessAt)
+
+	assert.Equal(t, hotels.SupplierDisabled, report.Suppliers[2].Status)
+}
+
+func TestFetchAndNormalize_CoercesValuesToTheirType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": "123", "destination": "5432"}, {"id": "456", "destination": "unknown"}]`)
+	}))
+	t.Cleanup(srv.Close)
+
+	engine, err := mapper.NewMappingEngine([]byte(`{
+		"id": { "src::flaky": "id" },
+		"destination_id": { "src::flaky": "destination", "type": "int" }
+	}`))
+	require.NoError(t, err)
+
+	result, report, err := hotels.FetchAndNormalize(context.Background(), engine, loadTestConfig(t, srv.URL, `{"max_attempts": 1}`))
+	require.NoError(t, err)
+	require.Len(t, result.Hotels, 2)
+
+	byId := map[string]hotels.Hotel{}
+	for _, h := range result.Hotels {
+		byId[h.Id] = h
+	}
+	assert.Equal(t, 5432, byId["123"].DestinationId)
+	assert.Equal(t, 0, byId["456"].DestinationId)
+
+	require.Len(t, report.CoercionFailures, 1)
+	assert.Equal(t, mapper.CoercionFailure{
+		HotelId:  "456",
+		Field:    "destination_id",
+		Supplier: "flaky",
+		Value:    "unknown",
+		Type:     "int",
+		Reason:   `"unknown" is not a number`,
+	}, report.CoercionFailures[0])
+}
+
+func TestFetchAndNormalize_QuarantinesHotelsThatCannotBeDecoded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": "123", "lat": 1.264751}, {"id": "456", "lat": "north"}]`)
+	}))
+	t.Cleanup(srv.Close)
+
+	engine, err := mapper.NewMappingEngine([]byte(`{
+		"id": { "src::flaky": "id" },
+		"location": { "lat": { "src::flaky": "lat" } }
+	}`))
+	require.NoError(t, err)
+
+	result, report, err := hotels.FetchAndNormalize(context.Background(), engine, loadTestConfig(t, srv.URL, `{"max_attempts": 1}`))
+	require.NoError(t, err)
+	require.Len(t, result.Hotels, 1)
+	assert.Equal(t, "123", result.Hotels[0].Id)
+	assert.Equal(t, 1, report.Hotels)
+
+	require.Len(t, report.Quarantined, 1)
+	assert.Equal(t, "456", report.Quarantined[0].HotelId)
+	assert.Contains(t, report.Quarantined[0].Reason, "location.lat")
+	assert.JSONEq(t, `{"id": "456", "location": {"lat": "north"}}`, string(report.Quarantined[0].Hotel))
+}
+
+func TestFetchAndNormalize_LeavesQuarantinedHotelsOutOfConflicts(t *testing.T) {
+	serve := func(body string) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	first := serve(`[{"id": "123", "name": "Hotel A"}, {"id": "456", "name": "Hotel B", "lat": "north"}]`)
+	second := serve(`[{"id": "123", "name": "Other Inn"}, {"id": "456", "name": "Other Lodge"}]`)
+
+	config, err := hotels.LoadConfig([]byte(fmt.Sprintf(`{
+		"suppliers": [
+			{"name": "flaky", "url": %q, "enabled": true},
+			{"name": "down", "url": %q, "enabled": true}
+		]
+	}`, first.URL, second.URL)))
+	require.NoError(t, err)
+	engine, err := mapper.NewMappingEngine([]byte(`{
+		"id": { "src::flaky": "id", "src::down": "id" },
+		"name": { "src::flaky": "name", "src::down": "name" },
+		"location": { "lat": { "src::flaky": "lat" } }
+	}`))
+	require.NoError(t, err)
+	engine.EnableConflictDetection()
+
+	result, report, err := hotels.FetchAndNormalize(context.Background(), engine, config)
+	require.NoError(t, err)
+	require.Len(t, report.Quarantined, 1)
+	require.Len(t, result.Conflicts, 1)
+	assert.Equal(t, "123", result.Conflicts[0].HotelId)
+}
+
+func TestFetchAndNormalize_FailsWhenEveryHotelIsQuarantined(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": "456", "lat": "north"}]`)
+	}))
+	t.Cleanup(srv.Close)
+
+	engine, err := mapper.NewMappingEngine([]byte(`{
+		"id": { "src::flaky": "id" },
+		"location": { "lat": { "src::flaky": "lat" } }
+	}`))
+	require.NoError(t, err)
+
+	result, report, err := hotels.FetchAndNormalize(context.Background(), engine, loadTestConfig(t, srv.URL, `{"max_attempts": 1}`))
+	require.ErrorIs(t, err, hotels.ErrAllHotelsQuarantined)
+	assert.Nil(t, result)
+	assert.Len(t, report.Quarantined, 1)
+	assert.Equal(t, hotels.ErrAllHotelsQuarantined.Error(), report.Error)
+}
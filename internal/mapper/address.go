@@ -0,0 +1,273 @@
+package mapper
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// address is a supplier address split into its components, e.g.
+// "1 Nanson Rd, Singapore 238909" is street "1 Nanson Road", city "Singapore" and postal code "238909"
+type address struct {
+	street     string // house number and street, e.g. "8 Sentosa Gateway"
+	building   string // building, block or estate, e.g. "Beach Villas"
+	postalCode string
+	city       string
+	country    string // country name from the ISO 3166 table
+}
+
+// outputs of merge_address
+const (
+	addressText  = "text"  // a single line, e.g. "8 Sentosa Gateway, Beach Villas, 098269"
+	addressParts = "parts" // an object with the components, see hotels.AddressParts
+)
+
+var addressOutputs = []string{addressText, addressParts}
+
+var (
+	postalCodePattern  = regexp.MustCompile(`^(?:\d{4,6}|\d{3}-\d{4})$`)             // Singapore, most of Europe, Japan...
+	cityPostalPattern  = regexp.MustCompile(`^(\D+?)\s+(\d{4,6}|\d{3}-\d{4})$`)      // "Singapore 238909"
+	houseNumberPattern = regexp.MustCompile(`^(?:no\.?\s*)?\d+[a-z]?(?:[-/]\d+)*\b`) // "8", "12a", "6-6-2"
+	spaceBeforeComma   = regexp.MustCompile(`\s+,`)
+)
+
+// streetAbbreviations are expanded when they end a segment, so "1 Nanson Rd" and "1 Nanson Road" are the same street
+var streetAbbreviations = map[string]string{
+	"rd":   "Road",
+	"st":   "Street",
+	"ave":  "Avenue",
+	"blvd": "Boulevard",
+	"ln":   "Lane",
+	"hwy":  "Highway",
+	"cres": "Crescent",
+	"ctr":  "Center",
+}
+
+// parseAddress splits a free text address on commas and recognizes its components:
+// the segment starting with a house number is the street, postal codes are recognized by their form,
+// the last segment, postal codes aside, is the country when it names one,
+// a segment before the street is the city, segments after it the building
+func parseAddress(text string) address {
+	var parsed address
+	var unknown []string
+	streetIndex := -1
+
+	var segments []string
+	for _, segment := range strings.Split(spaceBeforeComma.ReplaceAllString(text, ","), ",") {
+		if segment = strings.Join(strings.Fields(strings.Trim(segment, " .;")), " "); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	// a district or building named like a country, e.g. "Jersey", is not the country unless it ends the address
+	countryIndex := len(segments) - 1
+	for countryIndex > 0 && postalCodePattern.MatchString(segments[countryIndex]) {
+		countryIndex--
+	}
+
+	for i, segment := range segments {
+
+		if postalCodePattern.MatchString(segment) && (parsed.postalCode == "" || parsed.postalCode == segment) {
+			parsed.postalCode = segment // repeated by templates such as "{{Address}}, {{PostalCode}}"
+			continue
+		}
+		if match := cityPostalPattern.FindStringSubmatch(segment); match != nil && parsed.postalCode == "" {
+			parsed.city, parsed.postalCode = formatAddressWords(match[1]), match[2]
+			continue
+		}
+		if c, found := findCountry(segment); found && i == countryIndex {
+			parsed.country = c.name
+			continue
+		}
+		if streetIndex == -1 && houseNumberPattern.MatchString(strings.ToLower(segment)) {
+			parsed.street = formatAddressWords(segment)
+			streetIndex = len(unknown)
+			continue
+		}
+		unknown = append(unknown, formatAddressWords(segment))
+	}
+
+	var building []string
+	for i, segment := range unknown {
+		switch {
+		case streetIndex == -1 && parsed.street == "":
+			parsed.street = segment // no house number, e.g. "Marina Bay Sands"
+		case i < streetIndex && parsed.city == "":
+			parsed.city = segment
+		default:
+			building = append(building, segment)
+		}
+	}
+	parsed.building = strings.Join(building, ", ")
+	return parsed
+}
+
+// formatAddressWords expands a trailing street abbreviation and title cases segments written in capitals,
+// "6-6-2 NISHI-SHINJUKU" becomes "6-6-2 Nishi-Shinjuku" while "RWS Sentosa" is kept
+func formatAddressWords(segment string) string {
+	if strings.ToUpper(segment) == segment {
+		segment = titleCase(segment)
+	}
+
+	words := strings.Fields(segment)
+	last := len(words) - 1
+	if last < 0 {
+		return ""
+	}
+	if expanded, found := streetAbbreviations[strings.ToLower(strings.TrimSuffix(words[last], "."))]; found && last > 0 {
+		words[last] = expanded
+	}
+	return strings.Join(words, " ")
+}
+
+// titleCase upper cases the first letter of every word, or part of a hyphenated word, and lower cases the rest
+func titleCase(text string) string {
+	runes := []rune(strings.ToLower(text))
+	for i, r := range runes {
+		if i == 0 || !unicode.IsLetter(runes[i-1]) && runes[i-1] != '\'' {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+// text renders the address on a single line, e.g. "1 Nanson Road, Singapore 238909"
+func (a address) text() string {
+	var parts []string
+	for _, part := range []string{a.street, a.building, strings.TrimSpace(a.city + " " + a.postalCode), a.country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// object returns the non-empty components, keyed like hotels.AddressParts
+func (a address) object() map[string]interface{} {
+	parts := make(map[string]interface{})
+	for key, value := range map[string]string{
+		"street":      a.street,
+		"building":    a.building,
+		"postal_code": a.postalCode,
+		"city":        a.city,
+		"country":     a.country,
+	} {
+		if value != "" {
+			parts[key] = value
+		}
+	}
+	return parts
+}
+
+// equivalent reports whether two addresses designate the same place: same street, and same postal code,
+// city and country when both addresses have one, case, punctuation and abbreviations are ignored
+func (a address) equivalent(b address) bool {
+	sameOrMissing := func(x, y string) bool {
+		return x == "" || y == "" || addressKey(x) == addressKey(y)
+	}
+	return addressKey(a.street) == addressKey(b.street) &&
+		sameOrMissing(a.postalCode, b.postalCode) &&
+		sameOrMissing(a.city, b.city) &&
+		sameOrMissing(a.country, b.country)
+}
+
+// addressKey is the form in which address components are compared, "1 Nanson Rd." and "1 NANSON ROAD" are the same
+func addressKey(component string) string {
+	return strings.Join(termTokens(formatAddressWords(component), nil), "")
+}
+
+// mergeAddressAction builds the "merge_address[:<output>]" action, it parses the addresses of all suppliers,
+// keeps the ones equivalent to the most trusted address and completes its missing components from them
+func mergeAddressAction(params []string, _ map[string]*vocabulary) (actionPlan, error) {
+	output := addressText
+	switch {
+	case len(params) > 1:
+		return actionPlan{}, fmt.Errorf("expected merge_address or merge_address:<%s>", strings.Join(addressOutputs, "|"))
+	case len(params) == 1:
+		output = params[0]
+		if !slices.Contains(addressOutputs, output) {
+			return actionPlan{}, fmt.Errorf("unknown address output %q, expected one of %s", output, strings.Join(addressOutputs, ", "))
+		}
+	}
+
+	return actionPlan{
+		merge: func(m *MappingEngine, candidates []candidate, _ *fieldPlan, _ *hotelRecorder) interface{} {
+			merged, found := m.mergeAddresses(candidates)
+			switch {
+			case !found:
+				return nil
+			case output == addressParts:
+				return merged.object()
+			default:
+				return merged.text()
+			}
+		},
+		equivalent: func(a, b interface{}) bool {
+			strA, isStringA := a.(string)
+			strB, isStringB := b.(string)
+			return isStringA && isStringB && parseAddress(strA).equivalent(parseAddress(strB))
+		},
+	}, nil
+}
+
+// mergeAddresses groups the equivalent addresses of the candidates and merges the group with the highest total weight,
+// ties going to the group of the first candidate, each component is taken from the first candidate of the group having it
+func (m *MappingEngine) mergeAddresses(candidates []candidate) (address, bool) {
+	type group struct {
+		addresses []address
+		weight    float64
+	}
+
+	var groups []*group
+	for _, c := range candidates {
+		text, ok := c.value.(string)
+		if !ok {
+			continue
+		}
+
+		parsed := parseAddress(text)
+		if parsed == (address{}) {
+			continue
+		}
+		index := slices.IndexFunc(groups, func(g *group) bool { return g.addresses[0].equivalent(parsed) })
+		if index == -1 {
+			groups = append(groups, &group{})
+			index = len(groups) - 1
+		}
+		groups[index].addresses = append(groups[index].addresses, parsed)
+		groups[index].weight += c.weight
+	}
+	if len(groups) == 0 {
+		return address{}, false
+	}
+
+	best := groups[0]
+	for _, g := range groups[1:] {
+		if g.weight > best.weight {
+			best = g
+		}
+	}
+
+	var merged address
+	for _, a := range best.addresses {
+		for _, component := range []struct {
+			into *string
+			from string
+		}{
+			{&merged.street, a.street},
+			{&merged.building, a.building},
+			{&merged.postalCode, a.postalCode},
+			{&merged.city, a.city},
+			{&merged.country, a.country},
+		} {
+			if *component.into == "" {
+				*component.into = component.from
+			}
+		}
+	}
+	if merged.building != "" && addressKey(merged.building) == addressKey(merged.city) {
+		merged.building = "" // a district written after the street by one supplier and as the city by another
+	}
+	return merged, true
+}
@@ -0,0 +1,185 @@
+package mapper
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// types of the "type" key of a leaf mapping
+const (
+	typeInt        = "int"
+	typeFloat      = "float"
+	typeString     = "string"
+	typeBool       = "bool"
+	typeStringList = "string[]"
+)
+
+var valueTypes = []string{typeInt, typeFloat, typeString, typeBool, typeStringList}
+
+// coercions convert a non-empty supplier value to a scalar type, "string[]" lists are converted item by item
+var coercions = map[string]func(value interface{}) (interface{}, error){
+	typeInt:    coerceInt,
+	typeFloat:  coerceFloat,
+	typeString: coerceString,
+	typeBool:   coerceBool,
+}
+
+// CoercionFailure is a supplier value that could not be converted to the type of its field, the value is left out
+type CoercionFailure struct {
+	HotelId  string      `json:"hotel_id"`
+	Field    string      `json:"field"` // dotted output path, e.g. "destination_id"
+	Supplier string      `json:"supplier"`
+	Value    interface{} `json:"value"` // as read from the supplier, the item for "string[]" fields
+	Type     string      `json:"type"`
+	Reason   string      `json:"reason"`
+}
+
+// coerceCandidates converts the values of the candidates to the type of the field, before any action runs
+// empty values are dropped, values that cannot be converted are dropped and recorded as failures
+func (m *MappingEngine) coerceCandidates(field *fieldPlan, candidates []candidate, recorder *hotelRecorder) []candidate {
+	coerced := candidates[:0]
+	for _, c := range candidates {
+		if isEmptyValue(c.value) {
+			continue
+		}
+
+		if field.valueType == typeStringList {
+			c.value = m.coerceItems(field, c, recorder)
+			if len(c.value.([]interface{})) > 0 {
+				coerced = append(coerced, c)
+			}
+			continue
+		}
+
+		value, err := coercions[field.valueType](c.value)
+		if err != nil {
+			recorder.recordCoercionFailure(field, c.supplier, c.value, err)
+			continue
+		}
+		c.value = value
+		coerced = append(coerced, c)
+	}
+	return coerced
+}
+
+// coerceItems converts every item of a list to a string, a single value is a list of one item
+func (m *MappingEngine) coerceItems(field *fieldPlan, c candidate, recorder *hotelRecorder) []interface{} {
+	items, isList := c.value.([]interface{})
+	if !isList {
+		items = []interface{}{c.value}
+	}
+
+	coerced := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if isEmptyValue(item) {
+			continue
+		}
+		value, err := coerceString(item)
+		if err != nil {
+			recorder.recordCoercionFailure(field, c.supplier, item, err)
+			continue
+		}
+		coerced = append(coerced, value)
+	}
+	return coerced
+}
+
+// recordCoercionFailure keeps a value that could not be converted, a nil recorder discards it
+func (r *hotelRecorder) recordCoercionFailure(field *fieldPlan, supplier string, value interface{}, err error) {
+	if r == nil {
+		return
+	}
+	r.coercionFailures = append(r.coercionFailures, CoercionFailure{
+		HotelId:  r.hotelId,
+		Field:    field.name,
+		Supplier: supplier,
+		Value:    value,
+		Type:     field.valueType,
+		Reason:   err.Error(),
+	})
+}
+
+// coerceInt accepts integers, floats without a fractional part and strings holding one of them, e.g. "5432"
+func coerceInt(value interface{}) (interface{}, error) {
+	number, err := coerceNumber(value)
+	if err != nil {
+		return nil, err
+	}
+	if number != math.Trunc(number) || math.Abs(number) >= math.MaxInt64 {
+		return nil, fmt.Errorf("%v is not an integer", value)
+	}
+	return int64(number), nil
+}
+
+// coerceFloat accepts numbers and strings holding a number, e.g. "1.264751"
+func coerceFloat(value interface{}) (interface{}, error) {
+	return coerceNumber(value)
+}
+
+func coerceNumber(value interface{}) (float64, error) {
+	if str, ok := value.(string); ok {
+		number, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+			return 0, fmt.Errorf("%q is not a number", str)
+		}
+		return number, nil
+	}
+	if number, ok := toFloat(value); ok {
+		return number, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %s", describeType(value))
+}
+
+// coerceString accepts strings, trimmed, numbers and booleans, which are formatted
+func coerceString(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return nil, fmt.Errorf("expected a string, got %s", describeType(value))
+	}
+}
+
+// coerceBool accepts booleans, the numbers 0 and 1 and strings such as "true", "False", "1" or "no"
+func coerceBool(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "t", "yes", "y", "1":
+			return true, nil
+		case "false", "f", "no", "n", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a boolean", v)
+	}
+	if number, ok := toFloat(value); ok && (number == 0 || number == 1) {
+		return number == 1, nil
+	}
+	return nil, fmt.Errorf("%v is not a boolean", value)
+}
+
+// describeType names the JSON type of a value for error messages
+func describeType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return "a number"
+	}
+}
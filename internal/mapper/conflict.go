@@ -0,0 +1,162 @@
+package mapper
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Conflict is a field on which the suppliers of a hotel disagree beyond the field's tolerance
+type Conflict struct {
+	HotelId    string                 `json:"hotel_id"`
+	Field      string                 `json:"field"`      // dotted output path, e.g. "location.country"
+	Values     map[string]interface{} `json:"values"`     // key: supplier, value: as read from the supplier
+	Resolution string                 `json:"resolution"` // strategy or merge action that picked the value
+	Value      interface{}            `json:"value"`
+	Supplier   string                 `json:"supplier,omitempty"` // supplier of the value, empty if no single supplier provided it
+	Rejected   map[string]string      `json:"rejected,omitempty"` // key: supplier, value: why its value was left out
+}
+
+// Tolerance is how far supplier values may differ before they conflict
+type Tolerance struct {
+	NumericEpsilon   float64 `json:"numeric_epsilon"`   // numbers differing by at most this much agree
+	StringSimilarity float64 `json:"string_similarity"` // strings at least this similar agree, from 0 (any strings) to 1 (identical)
+	DistanceKm       float64 `json:"distance_km"`       // coordinates at most this far apart agree, see merge_coordinates
+}
+
+// defaultTolerance applies when the mapping has no "conflict_tolerance"
+var defaultTolerance = Tolerance{NumericEpsilon: 1e-6, StringSimilarity: 0.9, DistanceKm: 1}
+
+// EnableConflictDetection makes TransformDetailed report the fields on which suppliers disagree
+// it must be called before the engine is used
+func (m *MappingEngine) EnableConflictDetection() {
+	m.conflicts = true
+}
+
+// parseTolerance reads a validated tolerance object, missing settings are taken from base
+func parseTolerance(value interface{}, base Tolerance) Tolerance {
+	settings, _ := value.(map[string]interface{})
+	if epsilon, ok := settings["numeric_epsilon"].(float64); ok {
+		base.NumericEpsilon = epsilon
+	}
+	if similarity, ok := settings["string_similarity"].(float64); ok {
+		base.StringSimilarity = similarity
+	}
+	if distance, ok := settings["distance_km"].(float64); ok {
+		base.DistanceKm = distance
+	}
+	return base
+}
+
+// detectConflict compares the values the suppliers provided for a field
+// extracted holds the candidates as read from the suppliers, candidates the same after the value actions
+// fields merged by an action are skipped, combining different values is what the action is for,
+// unless the action tells which values are equivalent
+func (m *MappingEngine) detectConflict(field *fieldPlan, extracted, candidates []candidate, merged interface{}) (Conflict, bool) {
+	agree, resolution := field.tolerance.agree, field.strategyName
+	if field.mergeIndex < len(field.actions) {
+		action := field.actions[field.mergeIndex]
+		if action.equivalent == nil {
+			return Conflict{}, false
+		}
+		agree, resolution = action.equivalent, action.name
+	}
+
+	var compared []int
+	for i, c := range candidates {
+		if !isEmptyValue(c.value) && isScalar(c.value) {
+			compared = append(compared, i)
+		}
+	}
+
+	disagree := false
+	for a := 0; a < len(compared) && !disagree; a++ {
+		for b := a + 1; b < len(compared) && !disagree; b++ {
+			disagree = !agree(candidates[compared[a]].value, candidates[compared[b]].value)
+		}
+	}
+	if !disagree {
+		return Conflict{}, false
+	}
+
+	conflict := Conflict{
+		Field:      field.name,
+		Values:     make(map[string]interface{}, len(compared)),
+		Resolution: resolution,
+		Value:      merged,
+	}
+	for _, i := range compared {
+		conflict.Values[extracted[i].supplier] = extracted[i].value
+	}
+	for _, c := range candidates {
+		if sameValue(c.value, merged) {
+			conflict.Supplier = c.supplier
+			break
+		}
+	}
+	return conflict, true
+}
+
+// agree reports whether two supplier values are the same within the tolerance
+func (t Tolerance) agree(a, b interface{}) bool {
+	numberA, isNumberA := toFloat(a)
+	numberB, isNumberB := toFloat(b)
+	if isNumberA && isNumberB {
+		return math.Abs(numberA-numberB) <= t.NumericEpsilon
+	}
+
+	strA, isStringA := a.(string)
+	strB, isStringB := b.(string)
+	if isStringA && isStringB {
+		return similarity(strA, strB) >= t.StringSimilarity
+	}
+
+	return valueKey(a) == valueKey(b)
+}
+
+// similarity returns the Sørensen–Dice coefficient of the character bigrams of two strings,
+// compared case-insensitively with punctuation and repeated spaces removed, 1 for identical strings
+func similarity(a, b string) float64 {
+	a, b = comparableText(a), comparableText(b)
+	if a == b {
+		return 1
+	}
+
+	// bigrams of runes, not bytes, so "Café" and "Cafe" share "ca" and "af" instead of splitting "é"
+	runesA, runesB := []rune(a), []rune(b)
+	if len(runesA) < 2 || len(runesB) < 2 {
+		return 0
+	}
+
+	bigrams := make(map[[2]rune]int)
+	for i := 0; i < len(runesA)-1; i++ {
+		bigrams[[2]rune{runesA[i], runesA[i+1]}]++
+	}
+	shared := 0
+	for i := 0; i < len(runesB)-1; i++ {
+		bigram := [2]rune{runesB[i], runesB[i+1]}
+		if bigrams[bigram] > 0 {
+			bigrams[bigram]--
+			shared++
+		}
+	}
+	return float64(2*shared) / float64(len(runesA)-1+len(runesB)-1)
+}
+
+// comparableText lowercases a string and reduces it to words separated by single spaces
+func comparableText(str string) string {
+	words := strings.FieldsFunc(strings.ToLower(str), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// isScalar reports whether a value is a string, number or bool
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, int64, float64, int, bool:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,255 @@
+package mapper
+
+// countries is the ISO 3166-1 country table used by normalize_country, ordered by alpha-2 code
+// aliases are other names suppliers use for the country, e.g. the official name or a former one
+var countries = []country{
+	{alpha2: "AD", alpha3: "AND", name: "Andorra", aliases: []string{"Principality of Andorra"}},
+	{alpha2: "AE", alpha3: "ARE", name: "United Arab Emirates", aliases: []string{"UAE", "Emirates"}},
+	{alpha2: "AF", alpha3: "AFG", name: "Afghanistan", aliases: []string{"Islamic Republic of Afghanistan"}},
+	{alpha2: "AG", alpha3: "ATG", name: "Antigua and Barbuda"},
+	{alpha2: "AI", alpha3: "AIA", name: "Anguilla"},
+	{alpha2: "AL", alpha3: "ALB", name: "Albania", aliases: []string{"Republic of Albania"}},
+	{alpha2: "AM", alpha3: "ARM", name: "Armenia", aliases: []string{"Republic of Armenia"}},
+	{alpha2: "AO", alpha3: "AGO", name: "Angola", aliases: []string{"Republic of Angola"}},
+	{alpha2: "AQ", alpha3: "ATA", name: "Antarctica"},
+	{alpha2: "AR", alpha3: "ARG", name: "Argentina", aliases: []string{"Argentine Republic"}},
+	{alpha2: "AS", alpha3: "ASM", name: "American Samoa"},
+	{alpha2: "AT", alpha3: "AUT", name: "Austria", aliases: []string{"Republic of Austria"}},
+	{alpha2: "AU", alpha3: "AUS", name: "Australia"},
+	{alpha2: "AW", alpha3: "ABW", name: "Aruba"},
+	{alpha2: "AX", alpha3: "ALA", name: "Åland Islands", aliases: []string{"Aland Islands"}},
+	{alpha2: "AZ", alpha3: "AZE", name: "Azerbaijan", aliases: []string{"Republic of Azerbaijan"}},
+	{alpha2: "BA", alpha3: "BIH", name: "Bosnia and Herzegovina", aliases: []string{"Republic of Bosnia and Herzegovina"}},
+	{alpha2: "BB", alpha3: "BRB", name: "Barbados"},
+	{alpha2: "BD", alpha3: "BGD", name: "Bangladesh", aliases: []string{"People's Republic of Bangladesh"}},
+	{alpha2: "BE", alpha3: "BEL", name: "Belgium", aliases: []string{"Kingdom of Belgium"}},
+	{alpha2: "BF", alpha3: "BFA", name: "Burkina Faso"},
+	{alpha2: "BG", alpha3: "BGR", name: "Bulgaria", aliases: []string{"Republic of Bulgaria"}},
+	{alpha2: "BH", alpha3: "BHR", name: "Bahrain", aliases: []string{"Kingdom of Bahrain"}},
+	{alpha2: "BI", alpha3: "BDI", name: "Burundi", aliases: []string{"Republic of Burundi"}},
+	{alpha2: "BJ", alpha3: "BEN", name: "Benin", aliases: []string{"Republic of Benin"}},
+	{alpha2: "BL", alpha3: "BLM", name: "Saint Barthélemy", aliases: []string{"Saint Barthelemy"}},
+	{alpha2: "BM", alpha3: "BMU", name: "Bermuda"},
+	{alpha2: "BN", alpha3: "BRN", name: "Brunei Darussalam", aliases: []string{"Brunei"}},
+	{alpha2: "BO", alpha3: "BOL", name: "Bolivia", aliases: []string{"Bolivia, Plurinational State of", "Plurinational State of Bolivia"}},
+	{alpha2: "BQ", alpha3: "BES", name: "Bonaire, Sint Eustatius and Saba"},
+	{alpha2: "BR", alpha3: "BRA", name: "Brazil", aliases: []string{"Federative Republic of Brazil"}},
+	{alpha2: "BS", alpha3: "BHS", name: "Bahamas", aliases: []string{"Commonwealth of the Bahamas"}},
+	{alpha2: "BT", alpha3: "BTN", name: "Bhutan", aliases: []string{"Kingdom of Bhutan"}},
+	{alpha2: "BV", alpha3: "BVT", name: "Bouvet Island"},
+	{alpha2: "BW", alpha3: "BWA", name: "Botswana", aliases: []string{"Republic of Botswana"}},
+	{alpha2: "BY", alpha3: "BLR", name: "Belarus", aliases: []string{"Republic of Belarus"}},
+	{alpha2: "BZ", alpha3: "BLZ", name: "Belize"},
+	{alpha2: "CA", alpha3: "CAN", name: "Canada"},
+	{alpha2: "CC", alpha3: "CCK", name: "Cocos (Keeling) Islands"},
+	{alpha2: "CD", alpha3: "COD", name: "Congo, The Democratic Republic of the", aliases: []string{"DR Congo", "DRC", "Democratic Republic of the Congo", "Congo-Kinshasa"}},
+	{alpha2: "CF", alpha3: "CAF", name: "Central African Republic"},
+	{alpha2: "CG", alpha3: "COG", name: "Congo", aliases: []string{"Republic of the Congo", "Congo-Brazzaville"}},
+	{alpha2: "CH", alpha3: "CHE", name: "Switzerland", aliases: []string{"Swiss Confederation"}},
+	{alpha2: "CI", alpha3: "CIV", name: "Côte d'Ivoire", aliases: []string{"Republic of Côte d'Ivoire", "Ivory Coast", "Cote d'Ivoire", "Republic of Cote d'Ivoire"}},
+	{alpha2: "CK", alpha3: "COK", name: "Cook Islands"},
+	{alpha2: "CL", alpha3: "CHL", name: "Chile", aliases: []string{"Republic of Chile"}},
+	{alpha2: "CM", alpha3: "CMR", name: "Cameroon", aliases: []string{"Republic of Cameroon"}},
+	{alpha2: "CN", alpha3: "CHN", name: "China", aliases: []string{"People's Republic of China"}},
+	{alpha2: "CO", alpha3: "COL", name: "Colombia", aliases: []string{"Republic of Colombia"}},
+	{alpha2: "CR", alpha3: "CRI", name: "Costa Rica", aliases: []string{"Republic of Costa Rica"}},
+	{alpha2: "CU", alpha3: "CUB", name: "Cuba", aliases: []string{"Republic of Cuba"}},
+	{alpha2: "CV", alpha3: "CPV", name: "Cabo Verde", aliases: []string{"Republic of Cabo Verde", "Cape Verde"}},
+	{alpha2: "CW", alpha3: "CUW", name: "Curaçao", aliases: []string{"Curacao"}},
+	{alpha2: "CX", alpha3: "CXR", name: "Christmas Island"},
+	{alpha2: "CY", alpha3: "CYP", name: "Cyprus", aliases: []string{"Republic of Cyprus"}},
+	{alpha2: "CZ", alpha3: "CZE", name: "Czechia", aliases: []string{"Czech Republic"}},
+	{alpha2: "DE", alpha3: "DEU", name: "Germany", aliases: []string{"Federal Republic of Germany"}},
+	{alpha2: "DJ", alpha3: "DJI", name: "Djibouti", aliases: []string{"Republic of Djibouti"}},
+	{alpha2: "DK", alpha3: "DNK", name: "Denmark", aliases: []string{"Kingdom of Denmark"}},
+	{alpha2: "DM", alpha3: "DMA", name: "Dominica", aliases: []string{"Commonwealth of Dominica"}},
+	{alpha2: "DO", alpha3: "DOM", name: "Dominican Republic"},
+	{alpha2: "DZ", alpha3: "DZA", name: "Algeria", aliases: []string{"People's Democratic Republic of Algeria"}},
+	{alpha2: "EC", alpha3: "ECU", name: "Ecuador", aliases: []string{"Republic of Ecuador"}},
+	{alpha2: "EE", alpha3: "EST", name: "Estonia", aliases: []string{"Republic of Estonia"}},
+	{alpha2: "EG", alpha3: "EGY", name: "Egypt", aliases: []string{"Arab Republic of Egypt"}},
+	{alpha2: "EH", alpha3: "ESH", name: "Western Sahara"},
+	{alpha2: "ER", alpha3: "ERI", name: "Eritrea", aliases: []string{"the State of Eritrea"}},
+	{alpha2: "ES", alpha3: "ESP", name: "Spain", aliases: []string{"Kingdom of Spain"}},
+	{alpha2: "ET", alpha3: "ETH", name: "Ethiopia", aliases: []string{"Federal Democratic Republic of Ethiopia"}},
+	{alpha2: "FI", alpha3: "FIN", name: "Finland", aliases: []string{"Republic of Finland"}},
+	{alpha2: "FJ", alpha3: "FJI", name: "Fiji", aliases: []string{"Republic of Fiji"}},
+	{alpha2: "FK", alpha3: "FLK", name: "Falkland Islands (Malvinas)"},
+	{alpha2: "FM", alpha3: "FSM", name: "Micronesia, Federated States of", aliases: []string{"Federated States of Micronesia", "Micronesia"}},
+	{alpha2: "FO", alpha3: "FRO", name: "Faroe Islands"},
+	{alpha2: "FR", alpha3: "FRA", name: "France", aliases: []string{"French Republic"}},
+	{alpha2: "GA", alpha3: "GAB", name: "Gabon", aliases: []string{"Gabonese Republic"}},
+	{alpha2: "GB", alpha3: "GBR", name: "United Kingdom", aliases: []string{"United Kingdom of Great Britain and Northern Ireland", "UK", "U.K.", "Great Britain", "Britain", "England", "Scotland", "Wales", "Northern Ireland"}},
+	{alpha2: "GD", alpha3: "GRD", name: "Grenada"},
+	{alpha2: "GE", alpha3: "GEO", name: "Georgia"},
+	{alpha2: "GF", alpha3: "GUF", name: "French Guiana"},
+	{alpha2: "GG", alpha3: "GGY", name: "Guernsey"},
+	{alpha2: "GH", alpha3: "GHA", name: "Ghana", aliases: []string{"Republic of Ghana"}},
+	{alpha2: "GI", alpha3: "GIB", name: "Gibraltar"},
+	{alpha2: "GL", alpha3: "GRL", name: "Greenland"},
+	{alpha2: "GM", alpha3: "GMB", name: "Gambia", aliases: []string{"Republic of the Gambia"}},
+	{alpha2: "GN", alpha3: "GIN", name: "Guinea", aliases: []string{"Republic of Guinea"}},
+	{alpha2: "GP", alpha3: "GLP", name: "Guadeloupe"},
+	{alpha2: "GQ", alpha3: "GNQ", name: "Equatorial Guinea", aliases: []string{"Republic of Equatorial Guinea"}},
+	{alpha2: "GR", alpha3: "GRC", name: "Greece", aliases: []string{"Hellenic Republic"}},
+	{alpha2: "GS", alpha3: "SGS", name: "South Georgia and the South Sandwich Islands"},
+	{alpha2: "GT", alpha3: "GTM", name: "Guatemala", aliases: []string{"Republic of Guatemala"}},
+	{alpha2: "GU", alpha3: "GUM", name: "Guam"},
+	{alpha2: "GW", alpha3: "GNB", name: "Guinea-Bissau", aliases: []string{"Republic of Guinea-Bissau"}},
+	{alpha2: "GY", alpha3: "GUY", name: "Guyana", aliases: []string{"Republic of Guyana"}},
+	{alpha2: "HK", alpha3: "HKG", name: "Hong Kong", aliases: []string{"Hong Kong Special Administrative Region of China", "Hongkong"}},
+	{alpha2: "HM", alpha3: "HMD", name: "Heard Island and McDonald Islands"},
+	{alpha2: "HN", alpha3: "HND", name: "Honduras", aliases: []string{"Republic of Honduras"}},
+	{alpha2: "HR", alpha3: "HRV", name: "Croatia", aliases: []string{"Republic of Croatia"}},
+	{alpha2: "HT", alpha3: "HTI", name: "Haiti", aliases: []string{"Republic of Haiti"}},
+	{alpha2: "HU", alpha3: "HUN", name: "Hungary"},
+	{alpha2: "ID", alpha3: "IDN", name: "Indonesia", aliases: []string{"Republic of Indonesia"}},
+	{alpha2: "IE", alpha3: "IRL", name: "Ireland"},
+	{alpha2: "IL", alpha3: "ISR", name: "Israel", aliases: []string{"State of Israel"}},
+	{alpha2: "IM", alpha3: "IMN", name: "Isle of Man"},
+	{alpha2: "IN", alpha3: "IND", name: "India", aliases: []string{"Republic of India"}},
+	{alpha2: "IO", alpha3: "IOT", name: "British Indian Ocean Territory"},
+	{alpha2: "IQ", alpha3: "IRQ", name: "Iraq", aliases: []string{"Republic of Iraq"}},
+	{alpha2: "IR", alpha3: "IRN", name: "Iran", aliases: []string{"Iran, Islamic Republic of", "Islamic Republic of Iran"}},
+	{alpha2: "IS", alpha3: "ISL", name: "Iceland", aliases: []string{"Republic of Iceland"}},
+	{alpha2: "IT", alpha3: "ITA", name: "Italy", aliases: []string{"Italian Republic"}},
+	{alpha2: "JE", alpha3: "JEY", name: "Jersey"},
+	{alpha2: "JM", alpha3: "JAM", name: "Jamaica"},
+	{alpha2: "JO", alpha3: "JOR", name: "Jordan", aliases: []string{"Hashemite Kingdom of Jordan"}},
+	{alpha2: "JP", alpha3: "JPN", name: "Japan"},
+	{alpha2: "KE", alpha3: "KEN", name: "Kenya", aliases: []string{"Republic of Kenya"}},
+	{alpha2: "KG", alpha3: "KGZ", name: "Kyrgyzstan", aliases: []string{"Kyrgyz Republic"}},
+	{alpha2: "KH", alpha3: "KHM", name: "Cambodia", aliases: []string{"Kingdom of Cambodia"}},
+	{alpha2: "KI", alpha3: "KIR", name: "Kiribati", aliases: []string{"Republic of Kiribati"}},
+	{alpha2: "KM", alpha3: "COM", name: "Comoros", aliases: []string{"Union of the Comoros"}},
+	{alpha2: "KN", alpha3: "KNA", name: "Saint Kitts and Nevis"},
+	{alpha2: "KP", alpha3: "PRK", name: "North Korea", aliases: []string{"Korea, Democratic People's Republic of", "Democratic People's Republic of Korea"}},
+	{alpha2: "KR", alpha3: "KOR", name: "South Korea", aliases: []string{"Korea, Republic of", "Korea", "Republic of Korea"}},
+	{alpha2: "KW", alpha3: "KWT", name: "Kuwait", aliases: []string{"State of Kuwait"}},
+	{alpha2: "KY", alpha3: "CYM", name: "Cayman Islands"},
+	{alpha2: "KZ", alpha3: "KAZ", name: "Kazakhstan", aliases: []string{"Republic of Kazakhstan"}},
+	{alpha2: "LA", alpha3: "LAO", name: "Laos", aliases: []string{"Lao People's Democratic Republic"}},
+	{alpha2: "LB", alpha3: "LBN", name: "Lebanon", aliases: []string{"Lebanese Republic"}},
+	{alpha2: "LC", alpha3: "LCA", name: "Saint Lucia"},
+	{alpha2: "LI", alpha3: "LIE", name: "Liechtenstein", aliases: []string{"Principality of Liechtenstein"}},
+	{alpha2: "LK", alpha3: "LKA", name: "Sri Lanka", aliases: []string{"Democratic Socialist Republic of Sri Lanka"}},
+	{alpha2: "LR", alpha3: "LBR", name: "Liberia", aliases: []string{"Republic of Liberia"}},
+	{alpha2: "LS", alpha3: "LSO", name: "Lesotho", aliases: []string{"Kingdom of Lesotho"}},
+	{alpha2: "LT", alpha3: "LTU", name: "Lithuania", aliases: []string{"Republic of Lithuania"}},
+	{alpha2: "LU", alpha3: "LUX", name: "Luxembourg", aliases: []string{"Grand Duchy of Luxembourg"}},
+	{alpha2: "LV", alpha3: "LVA", name: "Latvia", aliases: []string{"Republic of Latvia"}},
+	{alpha2: "LY", alpha3: "LBY", name: "Libya"},
+	{alpha2: "MA", alpha3: "MAR", name: "Morocco", aliases: []string{"Kingdom of Morocco"}},
+	{alpha2: "MC", alpha3: "MCO", name: "Monaco", aliases: []string{"Principality of Monaco"}},
+	{alpha2: "MD", alpha3: "MDA", name: "Moldova", aliases: []string{"Moldova, Republic of", "Republic of Moldova"}},
+	{alpha2: "ME", alpha3: "MNE", name: "Montenegro"},
+	{alpha2: "MF", alpha3: "MAF", name: "Saint Martin (French part)"},
+	{alpha2: "MG", alpha3: "MDG", name: "Madagascar", aliases: []string{"Republic of Madagascar"}},
+	{alpha2: "MH", alpha3: "MHL", name: "Marshall Islands", aliases: []string{"Republic of the Marshall Islands"}},
+	{alpha2: "MK", alpha3: "MKD", name: "North Macedonia", aliases: []string{"Republic of North Macedonia", "Macedonia"}},
+	{alpha2: "ML", alpha3: "MLI", name: "Mali", aliases: []string{"Republic of Mali"}},
+	{alpha2: "MM", alpha3: "MMR", name: "Myanmar", aliases: []string{"Republic of Myanmar", "Burma"}},
+	{alpha2: "MN", alpha3: "MNG", name: "Mongolia"},
+	{alpha2: "MO", alpha3: "MAC", name: "Macao", aliases: []string{"Macao Special Administrative Region of China", "Macau"}},
+	{alpha2: "MP", alpha3: "MNP", name: "Northern Mariana Islands", aliases: []string{"Commonwealth of the Northern Mariana Islands"}},
+	{alpha2: "MQ", alpha3: "MTQ", name: "Martinique"},
+	{alpha2: "MR", alpha3: "MRT", name: "Mauritania", aliases: []string{"Islamic Republic of Mauritania"}},
+	{alpha2: "MS", alpha3: "MSR", name: "Montserrat"},
+	{alpha2: "MT", alpha3: "MLT", name: "Malta", aliases: []string{"Republic of Malta"}},
+	{alpha2: "MU", alpha3: "MUS", name: "Mauritius", aliases: []string{"Republic of Mauritius"}},
+	{alpha2: "MV", alpha3: "MDV", name: "Maldives", aliases: []string{"Republic of Maldives"}},
+	{alpha2: "MW", alpha3: "MWI", name: "Malawi", aliases: []string{"Republic of Malawi"}},
+	{alpha2: "MX", alpha3: "MEX", name: "Mexico", aliases: []string{"United Mexican States"}},
+	{alpha2: "MY", alpha3: "MYS", name: "Malaysia"},
+	{alpha2: "MZ", alpha3: "MOZ", name: "Mozambique", aliases: []string{"Republic of Mozambique"}},
+	{alpha2: "NA", alpha3: "NAM", name: "Namibia", aliases: []string{"Republic of Namibia"}},
+	{alpha2: "NC", alpha3: "NCL", name: "New Caledonia"},
+	{alpha2: "NE", alpha3: "NER", name: "Niger", aliases: []string{"Republic of the Niger"}},
+	{alpha2: "NF", alpha3: "NFK", name: "Norfolk Island"},
+	{alpha2: "NG", alpha3: "NGA", name: "Nigeria", aliases: []string{"Federal Republic of Nigeria"}},
+	{alpha2: "NI", alpha3: "NIC", name: "Nicaragua", aliases: []string{"Republic of Nicaragua"}},
+	{alpha2: "NL", alpha3: "NLD", name: "Netherlands", aliases: []string{"Kingdom of the Netherlands", "Holland", "The Netherlands"}},
+	{alpha2: "NO", alpha3: "NOR", name: "Norway", aliases: []string{"Kingdom of Norway"}},
+	{alpha2: "NP", alpha3: "NPL", name: "Nepal", aliases: []string{"Federal Democratic Republic of Nepal"}},
+	{alpha2: "NR", alpha3: "NRU", name: "Nauru", aliases: []string{"Republic of Nauru"}},
+	{alpha2: "NU", alpha3: "NIU", name: "Niue"},
+	{alpha2: "NZ", alpha3: "NZL", name: "New Zealand"},
+	{alpha2: "OM", alpha3: "OMN", name: "Oman", aliases: []string{"Sultanate of Oman"}},
+	{alpha2: "PA", alpha3: "PAN", name: "Panama", aliases: []string{"Republic of Panama"}},
+	{alpha2: "PE", alpha3: "PER", name: "Peru", aliases: []string{"Republic of Peru"}},
+	{alpha2: "PF", alpha3: "PYF", name: "French Polynesia"},
+	{alpha2: "PG", alpha3: "PNG", name: "Papua New Guinea", aliases: []string{"Independent State of Papua New Guinea"}},
+	{alpha2: "PH", alpha3: "PHL", name: "Philippines", aliases: []string{"Republic of the Philippines"}},
+	{alpha2: "PK", alpha3: "PAK", name: "Pakistan", aliases: []string{"Islamic Republic of Pakistan"}},
+	{alpha2: "PL", alpha3: "POL", name: "Poland", aliases: []string{"Republic of Poland"}},
+	{alpha2: "PM", alpha3: "SPM", name: "Saint Pierre and Miquelon"},
+	{alpha2: "PN", alpha3: "PCN", name: "Pitcairn"},
+	{alpha2: "PR", alpha3: "PRI", name: "Puerto Rico"},
+	{alpha2: "PS", alpha3: "PSE", name: "Palestine, State of", aliases: []string{"the State of Palestine", "Palestine"}},
+	{alpha2: "PT", alpha3: "PRT", name: "Portugal", aliases: []string{"Portuguese Republic"}},
+	{alpha2: "PW", alpha3: "PLW", name: "Palau", aliases: []string{"Republic of Palau"}},
+	{alpha2: "PY", alpha3: "PRY", name: "Paraguay", aliases: []string{"Republic of Paraguay"}},
+	{alpha2: "QA", alpha3: "QAT", name: "Qatar", aliases: []string{"State of Qatar"}},
+	{alpha2: "RE", alpha3: "REU", name: "Réunion", aliases: []string{"Reunion"}},
+	{alpha2: "RO", alpha3: "ROU", name: "Romania"},
+	{alpha2: "RS", alpha3: "SRB", name: "Serbia", aliases: []string{"Republic of Serbia"}},
+	{alpha2: "RU", alpha3: "RUS", name: "Russian Federation", aliases: []string{"Russia"}},
+	{alpha2: "RW", alpha3: "RWA", name: "Rwanda", aliases: []string{"Rwandese Republic"}},
+	{alpha2: "SA", alpha3: "SAU", name: "Saudi Arabia", aliases: []string{"Kingdom of Saudi Arabia"}},
+	{alpha2: "SB", alpha3: "SLB", name: "Solomon Islands"},
+	{alpha2: "SC", alpha3: "SYC", name: "Seychelles", aliases: []string{"Republic of Seychelles"}},
+	{alpha2: "SD", alpha3: "SDN", name: "Sudan", aliases: []string{"Republic of the Sudan"}},
+	{alpha2: "SE", alpha3: "SWE", name: "Sweden", aliases: []string{"Kingdom of Sweden"}},
+	{alpha2: "SG", alpha3: "SGP", name: "Singapore", aliases: []string{"Republic of Singapore"}},
+	{alpha2: "SH", alpha3: "SHN", name: "Saint Helena, Ascension and Tristan da Cunha"},
+	{alpha2: "SI", alpha3: "SVN", name: "Slovenia", aliases: []string{"Republic of Slovenia"}},
+	{alpha2: "SJ", alpha3: "SJM", name: "Svalbard and Jan Mayen"},
+	{alpha2: "SK", alpha3: "SVK", name: "Slovakia", aliases: []string{"Slovak Republic"}},
+	{alpha2: "SL", alpha3: "SLE", name: "Sierra Leone", aliases: []string{"Republic of Sierra Leone"}},
+	{alpha2: "SM", alpha3: "SMR", name: "San Marino", aliases: []string{"Republic of San Marino"}},
+	{alpha2: "SN", alpha3: "SEN", name: "Senegal", aliases: []string{"Republic of Senegal"}},
+	{alpha2: "SO", alpha3: "SOM", name: "Somalia", aliases: []string{"Federal Republic of Somalia"}},
+	{alpha2: "SR", alpha3: "SUR", name: "Suriname", aliases: []string{"Republic of Suriname"}},
+	{alpha2: "SS", alpha3: "SSD", name: "South Sudan", aliases: []string{"Republic of South Sudan"}},
+	{alpha2: "ST", alpha3: "STP", name: "Sao Tome and Principe", aliases: []string{"Democratic Republic of Sao Tome and Principe"}},
+	{alpha2: "SV", alpha3: "SLV", name: "El Salvador", aliases: []string{"Republic of El Salvador"}},
+	{alpha2: "SX", alpha3: "SXM", name: "Sint Maarten (Dutch part)"},
+	{alpha2: "SY", alpha3: "SYR", name: "Syria", aliases: []string{"Syrian Arab Republic"}},
+	{alpha2: "SZ", alpha3: "SWZ", name: "Eswatini", aliases: []string{"Kingdom of Eswatini", "Swaziland"}},
+	{alpha2: "TC", alpha3: "TCA", name: "Turks and Caicos Islands"},
+	{alpha2: "TD", alpha3: "TCD", name: "Chad", aliases: []string{"Republic of Chad"}},
+	{alpha2: "TF", alpha3: "ATF", name: "French Southern Territories"},
+	{alpha2: "TG", alpha3: "TGO", name: "Togo", aliases: []string{"Togolese Republic"}},
+	{alpha2: "TH", alpha3: "THA", name: "Thailand", aliases: []string{"Kingdom of Thailand"}},
+	{alpha2: "TJ", alpha3: "TJK", name: "Tajikistan", aliases: []string{"Republic of Tajikistan"}},
+	{alpha2: "TK", alpha3: "TKL", name: "Tokelau"},
+	{alpha2: "TL", alpha3: "TLS", name: "Timor-Leste", aliases: []string{"Democratic Republic of Timor-Leste", "East Timor"}},
+	{alpha2: "TM", alpha3: "TKM", name: "Turkmenistan"},
+	{alpha2: "TN", alpha3: "TUN", name: "Tunisia", aliases: []string{"Republic of Tunisia"}},
+	{alpha2: "TO", alpha3: "TON", name: "Tonga", aliases: []string{"Kingdom of Tonga"}},
+	{alpha2: "TR", alpha3: "TUR", name: "Türkiye", aliases: []string{"Republic of Türkiye", "Turkey", "Turkiye", "Republic of Turkiye"}},
+	{alpha2: "TT", alpha3: "TTO", name: "Trinidad and Tobago", aliases: []string{"Republic of Trinidad and Tobago"}},
+	{alpha2: "TV", alpha3: "TUV", name: "Tuvalu"},
+	{alpha2: "TW", alpha3: "TWN", name: "Taiwan", aliases: []string{"Taiwan, Province of China"}},
+	{alpha2: "TZ", alpha3: "TZA", name: "Tanzania", aliases: []string{"Tanzania, United Republic of", "United Republic of Tanzania"}},
+	{alpha2: "UA", alpha3: "UKR", name: "Ukraine"},
+	{alpha2: "UG", alpha3: "UGA", name: "Uganda", aliases: []string{"Republic of Uganda"}},
+	{alpha2: "UM", alpha3: "UMI", name: "United States Minor Outlying Islands"},
+	{alpha2: "US", alpha3: "USA", name: "United States", aliases: []string{"United States of America", "USA", "U.S.A.", "U.S.", "America"}},
+	{alpha2: "UY", alpha3: "URY", name: "Uruguay", aliases: []string{"Eastern Republic of Uruguay"}},
+	{alpha2: "UZ", alpha3: "UZB", name: "Uzbekistan", aliases: []string{"Republic of Uzbekistan"}},
+	{alpha2: "VA", alpha3: "VAT", name: "Holy See (Vatican City State)", aliases: []string{"Vatican", "Vatican City"}},
+	{alpha2: "VC", alpha3: "VCT", name: "Saint Vincent and the Grenadines"},
+	{alpha2: "VE", alpha3: "VEN", name: "Venezuela", aliases: []string{"Venezuela, Bolivarian Republic of", "Bolivarian Republic of Venezuela"}},
+	{alpha2: "VG", alpha3: "VGB", name: "Virgin Islands, British", aliases: []string{"British Virgin Islands"}},
+	{alpha2: "VI", alpha3: "VIR", name: "Virgin Islands, U.S.", aliases: []string{"Virgin Islands of the United States"}},
+	{alpha2: "VN", alpha3: "VNM", name: "Vietnam", aliases: []string{"Viet Nam", "Socialist Republic of Viet Nam"}},
+	{alpha2: "VU", alpha3: "VUT", name: "Vanuatu", aliases: []string{"Republic of Vanuatu"}},
+	{alpha2: "WF", alpha3: "WLF", name: "Wallis and Futuna"},
+	{alpha2: "WS", alpha3: "WSM", name: "Samoa", aliases: []string{"Independent State of Samoa"}},
+	{alpha2: "YE", alpha3: "YEM", name: "Yemen", aliases: []string{"Republic of Yemen"}},
+	{alpha2: "YT", alpha3: "MYT", name: "Mayotte"},
+	{alpha2: "ZA", alpha3: "ZAF", name: "South Africa", aliases: []string{"Republic of South Africa"}},
+	{alpha2: "ZM", alpha3: "ZMB", name: "Zambia", aliases: []string{"Republic of Zambia"}},
+	{alpha2: "ZW", alpha3: "ZWE", name: "Zimbabwe", aliases: []string{"Republic of Zimbabwe"}},
+}
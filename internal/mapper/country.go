@@ -0,0 +1,103 @@
+package mapper
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// country is an entry of the ISO 3166-1 table
+type country struct {
+	alpha2  string // e.g. "SG"
+	alpha3  string // e.g. "SGP"
+	name    string // short English name, e.g. "Singapore"
+	aliases []string
+}
+
+// outputs of normalize_country
+const (
+	countryName   = "name"
+	countryAlpha2 = "alpha2"
+	countryAlpha3 = "alpha3"
+)
+
+var countryOutputs = []string{countryName, countryAlpha2, countryAlpha3}
+
+// countriesVocabulary is the vocabulary unmatched country values are reported under
+const countriesVocabulary = "countries"
+
+// countryLookup indexes the codes, names and aliases of every country by their termKey
+var countryLookup = sync.OnceValue(func() map[string]*country {
+	lookup := make(map[string]*country)
+	for i := range countries {
+		c := &countries[i]
+		for _, key := range append([]string{c.alpha2, c.alpha3, c.name}, c.aliases...) {
+			lookup[strings.Join(termTokens(key, nil), "")] = c
+		}
+	}
+	return lookup
+})
+
+// findCountry resolves an ISO alpha-2 or alpha-3 code, a name or an alias, ignoring case, punctuation and spacing
+func findCountry(value string) (*country, bool) {
+	c, found := countryLookup()[strings.Join(termTokens(value, nil), "")]
+	return c, found
+}
+
+// format returns the country in one of the countryOutputs
+func (c *country) format(output string) string {
+	switch output {
+	case countryAlpha2:
+		return c.alpha2
+	case countryAlpha3:
+		return c.alpha3
+	default:
+		return c.name
+	}
+}
+
+// normalizeCountryAction builds the "normalize_country[:<output>]" action, it replaces country codes and names
+// by the country name, alpha-2 or alpha-3 code, values that are not a known country are kept trimmed and recorded as unmatched
+func normalizeCountryAction(params []string, _ map[string]*vocabulary) (actionPlan, error) {
+	output := countryName
+	switch {
+	case len(params) > 1:
+		return actionPlan{}, fmt.Errorf("expected normalize_country or normalize_country:<%s>", strings.Join(countryOutputs, "|"))
+	case len(params) == 1:
+		output = params[0]
+		if !slices.Contains(countryOutputs, output) {
+			return actionPlan{}, fmt.Errorf("unknown country output %q, expected one of %s", output, strings.Join(countryOutputs, ", "))
+		}
+	}
+
+	return actionPlan{value: func(m *MappingEngine, value interface{}, supplier string, recorder *hotelRecorder) interface{} {
+		return m.normalizeCountry(value, output, supplier, recorder)
+	}}, nil
+}
+
+// normalizeCountry formats a country value, or every country of a list
+func (m *MappingEngine) normalizeCountry(value interface{}, output, supplier string, recorder *hotelRecorder) interface{} {
+	switch v := value.(type) {
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return value
+		}
+		c, found := findCountry(v)
+		if !found {
+			recorder.recordUnmatched(unmatchedTerm{vocabulary: countriesVocabulary, term: strings.TrimSpace(v), supplier: supplier})
+			return strings.TrimSpace(v) // better than no country when it is the only supplier sending one
+		}
+		return c.format(output)
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if normalized := m.normalizeCountry(item, output, supplier, recorder); normalized != nil {
+				result = append(result, normalized)
+			}
+		}
+		return result
+	default:
+		return value
+	}
+}
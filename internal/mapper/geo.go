@@ -0,0 +1,211 @@
+package mapper
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// coordinates is a point read from a supplier as an object, e.g. with the path "{lat:Latitude,lng:Longitude}"
+type coordinates struct {
+	lat, lng float64
+}
+
+// components of merge_coordinates
+const (
+	coordinateLat = "lat"
+	coordinateLng = "lng"
+)
+
+// consensus of merge_coordinates
+const (
+	consensusMedian  = "median"  // median latitude and longitude of the suppliers
+	consensusTrusted = "trusted" // coordinates of the most trusted supplier
+)
+
+var (
+	coordinateComponents = []string{coordinateLat, coordinateLng}
+	consensusKinds       = []string{consensusMedian, consensusTrusted}
+)
+
+const earthRadiusKm = 6371.0088
+
+// mergeCoordinatesAction builds the "merge_coordinates:<lat|lng>[:<consensus>]" action,
+// it validates the coordinates of every supplier, agrees on a point and returns its latitude or longitude
+// suppliers whose coordinates are invalid or farther than the field's distance_km tolerance from the point
+// are left out and reported as a conflict of the latitude field
+func mergeCoordinatesAction(params []string, _ map[string]*vocabulary) (actionPlan, error) {
+	if len(params) < 1 || len(params) > 2 {
+		return actionPlan{}, fmt.Errorf("expected merge_coordinates:<%s> or merge_coordinates:<%s>:<%s>",
+			strings.Join(coordinateComponents, "|"), strings.Join(coordinateComponents, "|"), strings.Join(consensusKinds, "|"))
+	}
+
+	component := params[0]
+	if !slices.Contains(coordinateComponents, component) {
+		return actionPlan{}, fmt.Errorf("unknown coordinate %q, expected one of %s", component, strings.Join(coordinateComponents, ", "))
+	}
+	consensus := consensusMedian
+	if len(params) == 2 {
+		consensus = params[1]
+		if !slices.Contains(consensusKinds, consensus) {
+			return actionPlan{}, fmt.Errorf("unknown consensus %q, expected one of %s", consensus, strings.Join(consensusKinds, ", "))
+		}
+	}
+
+	return actionPlan{merge: func(m *MappingEngine, candidates []candidate, field *fieldPlan, recorder *hotelRecorder) interface{} {
+		point, supplier, rejected, found := m.mergeCoordinates(candidates, consensus, field.tolerance.DistanceKm)
+
+		var merged interface{}
+		if found && component == coordinateLat {
+			merged = point.lat
+		} else if found {
+			merged = point.lng
+		}
+
+		// the lat and lng fields reject the same suppliers, the conflict is recorded once, on the lat field
+		if m.conflicts && recorder != nil && len(rejected) > 0 && component == coordinateLat {
+			conflict := Conflict{
+				HotelId:    recorder.hotelId,
+				Field:      field.name,
+				Values:     make(map[string]interface{}, len(candidates)),
+				Resolution: field.actions[field.mergeIndex].name,
+				Value:      merged,
+				Supplier:   supplier,
+				Rejected:   rejected,
+			}
+			for _, c := range candidates {
+				conflict.Values[c.supplier] = c.value
+			}
+			recorder.conflicts = append(recorder.conflicts, conflict)
+		}
+		return merged
+	}}, nil
+}
+
+// mergeCoordinates agrees on a point from the coordinates of the candidates, supplier is set when the point is
+// the one of a single supplier, rejected tells why the coordinates of other suppliers were left out
+func (m *MappingEngine) mergeCoordinates(candidates []candidate, consensus string, radiusKm float64) (point coordinates, supplier string, rejected map[string]string, found bool) {
+	rejected = make(map[string]string)
+	reject := func(supplier, reason string) {
+		if _, exists := rejected[supplier]; !exists {
+			rejected[supplier] = reason
+		}
+	}
+
+	type located struct {
+		supplier string
+		point    coordinates
+	}
+	var valid []located
+	for _, c := range candidates {
+		point, present, err := readCoordinates(c.value)
+		switch {
+		case !present:
+		case err != nil:
+			reject(c.supplier, err.Error())
+		default:
+			valid = append(valid, located{c.supplier, point})
+		}
+	}
+	if len(valid) == 0 {
+		return coordinates{}, "", rejected, false
+	}
+
+	points := make([]coordinates, len(valid))
+	for i, v := range valid {
+		points[i] = v.point
+	}
+
+	trusted := valid[0]
+	point, supplier = trusted.point, trusted.supplier
+	if consensus == consensusMedian {
+		point, supplier = medianPoint(points), ""
+		if !slices.ContainsFunc(points, func(p coordinates) bool { return haversineKm(p, point) <= radiusKm }) {
+			point, supplier = trusted.point, trusted.supplier // no supplier is close to the median, they all disagree
+		}
+	}
+
+	var inliers []coordinates
+	for _, v := range valid {
+		distance := haversineKm(v.point, point)
+		switch {
+		case distance <= radiusKm:
+			inliers = append(inliers, v.point)
+		case haversineKm(coordinates{lat: v.point.lng, lng: v.point.lat}, point) <= radiusKm:
+			reject(v.supplier, "latitude and longitude are swapped")
+		default:
+			reject(v.supplier, fmt.Sprintf("%.1f km away from the other suppliers", distance))
+		}
+	}
+	if consensus == consensusMedian && supplier == "" {
+		point = medianPoint(inliers) // without the outliers
+	}
+	return point, supplier, rejected, true
+}
+
+// readCoordinates reads a {"lat": ..., "lng": ...} object, present is false when the supplier has no coordinates
+// err tells why coordinates that are present cannot be used
+// the point is not checked against the country of the hotel, the country table has no boundaries
+func readCoordinates(value interface{}) (point coordinates, present bool, err error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return coordinates{}, !isEmptyValue(value), fmt.Errorf(`expected an object with "lat" and "lng"`)
+	}
+
+	lat, hasLat := coordinateNumber(object[coordinateLat])
+	lng, hasLng := coordinateNumber(object[coordinateLng])
+	switch {
+	case isEmptyValue(object[coordinateLat]) && isEmptyValue(object[coordinateLng]):
+		return coordinates{}, false, nil
+	case !hasLat || !hasLng:
+		return coordinates{}, true, fmt.Errorf("latitude and longitude must both be numbers")
+	case math.Abs(lat) > 90 && math.Abs(lng) <= 90:
+		return coordinates{}, true, fmt.Errorf("latitude and longitude are swapped")
+	case math.Abs(lat) > 90 || math.Abs(lng) > 180:
+		return coordinates{}, true, fmt.Errorf("coordinates out of range")
+	case lat == 0 && lng == 0:
+		return coordinates{}, true, fmt.Errorf("coordinates are (0, 0), a placeholder for unknown coordinates")
+	}
+	return coordinates{lat: lat, lng: lng}, true, nil
+}
+
+// coordinateNumber reads a number, suppliers sometimes send them as strings
+func coordinateNumber(value interface{}) (float64, bool) {
+	if str, ok := value.(string); ok {
+		number, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		return number, err == nil
+	}
+	return toFloat(value)
+}
+
+// medianPoint returns the median latitude and median longitude of the points
+func medianPoint(points []coordinates) coordinates {
+	lats := make([]float64, len(points))
+	lngs := make([]float64, len(points))
+	for i, p := range points {
+		lats[i], lngs[i] = p.lat, p.lng
+	}
+	return coordinates{lat: median(lats), lng: median(lngs)}
+}
+
+func median(values []float64) float64 {
+	slices.Sort(values)
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[middle-1] + values[middle]) / 2
+	}
+	return values[middle]
+}
+
+// haversineKm returns the great-circle distance between two points
+func haversineKm(a, b coordinates) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLat := toRadians(b.lat - a.lat)
+	dLng := toRadians(b.lng - a.lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(a.lat))*math.Cos(toRadians(b.lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
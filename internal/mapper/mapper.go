@@ -3,7 +3,10 @@ package mapper
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/tidwall/gjson"
@@ -11,56 +14,23 @@ import (
 
 const dataSupplierPrefix = "src::"
 
-// list of all known general amenities
-// if value from supplier is not in list, discard value
-var generalAmenity = map[string]string{
-	"businesscenter":  "business center",
-	"business center": "business center",
-	"gym":             "gym",
-	"outdoor pool":    "outdoor pool",
-	"indoor pool":     "indoor pool",
-	"pool":            "outdoor pool", // NOTE: assuming pool means outdoor pool
-	"airport shuttle": "airport shuttle",
-	"childcare":       "childcare",
-	"wifi":            "wifi",
-	"drycleaning":     "dry cleaning",
-	"dry cleaning":    "dry cleaning",
-	"breakfast":       "breakfast",
-	"bar":             "bar",       // NOTE: not in sample result.json but included for completeness, also assumed it's a general amenetity
-	"parking":         "parking",   // NOTE: not in sample result.json but included for completeness, also assumed it's a general amenetity
-	"concierge":       "concierge", // NOTE: not in sample result.json but included for completeness, also assumed it's a general amenetity
-}
-
-// list of all known room amenities
-// if value from supplier is not in list, discard value
-var roomAmenity = map[string]string{
-	"aircon":         "aircon",
-	"tv":             "tv",
-	"coffee machine": "coffee machine",
-	"kettle":         "kettle",
-	"hair dryer":     "hair dryer",
-	"iron":           "iron",
-	"bathtub":        "bathtub",
-	"tub":            "bathtub",
-	"minibar":        "minibar", // NOTE: not in sample result.json but included for completeness, kept in room amenity as in sample_3.json
-}
-
 // MappingEngine handles data transformation based on mapping configuration
 type MappingEngine struct {
-	config MappingConfig
+	config  MappingConfig
+	plan    []*fieldPlan       // compiled leaf mappings, executed for every hotel
+	idPaths map[string]string  // key: supplier name, value: path of the hotel id
+	weights map[string]float64 // key: supplier name, value: default trust weight from "supplier_weights"
+
+	provenance bool // record where every output value came from, see EnableProvenance
+	conflicts  bool // record values the suppliers disagree on, see EnableConflictDetection
+	tolerance  Tolerance
+
+	vocabularies map[string]*vocabulary // key: vocabulary name
 }
 
 // MappingConfig represents the structure of mapping.json
 type MappingConfig map[string]interface{}
 
-// FieldMapping represents a field mapping with supplier paths and actions
-type FieldMapping struct {
-	SupplierPaths           map[string]interface{} // key: supplier_1, supplier_2, supplier_3, value: jsonpath or template
-	Actions                 []string               // actions to apply
-	ObjectArrayFieldMapping map[string][]string    // key: field name, value: possible supplier field names, used for merging object arrays
-
-}
-
 // SupplierData holds data from all suppliers
 type SupplierData map[string]json.RawMessage
 
@@ -73,16 +43,54 @@ func NewMappingEngine(mappingJSON []byte) (*MappingEngine, error) {
 	if err := json.Unmarshal(mappingJSON, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse mapping config: %w", err)
 	}
+	if errs := validateConfig(config); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid mapping config: %w", errs)
+	}
+
+	vocabularies, _ := parseVocabularies(config[vocabulariesKey]) // validated above
 
 	engine := &MappingEngine{
-		config: config,
+		config:       config,
+		vocabularies: vocabularies,
+		weights:      parseWeights(config[supplierWeightsKey]),
+		tolerance:    parseTolerance(config[conflictToleranceKey], defaultTolerance),
 	}
+	engine.plan = engine.compile()
+	engine.idPaths = engine.extractIdFieldMapping()
 
 	return engine, nil
 }
 
+// TransformResult is the output of TransformDetailed
+type TransformResult struct {
+	Hotels     json.RawMessage            // merged hotels, as returned by Transform
+	Provenance map[string]HotelProvenance // key: hotel id, nil unless provenance is enabled
+	Conflicts  []Conflict                 // ordered by hotel id and field, nil unless conflict detection is enabled
+	Unmatched  []UnmatchedTerm            // supplier values not found in their vocabulary per supplier, most frequent first
+
+	CoercionFailures []CoercionFailure // supplier values left out because they do not have the type of their field, ordered by hotel id
+}
+
+// hotelRecorder collects what is recorded while merging a single hotel
+type hotelRecorder struct {
+	hotelId          string
+	provenance       HotelProvenance // nil unless provenance is enabled
+	conflicts        []Conflict
+	unmatched        []unmatchedTerm
+	coercionFailures []CoercionFailure
+}
+
 // Transform applies the mapping to supplier data
 func (m *MappingEngine) Transform(suppliers SupplierData) (json.RawMessage, error) {
+	result, err := m.TransformDetailed(suppliers)
+	if err != nil {
+		return nil, err
+	}
+	return result.Hotels, nil
+}
+
+// TransformDetailed applies the mapping to supplier data and returns what was recorded while merging
+func (m *MappingEngine) TransformDetailed(suppliers SupplierData) (*TransformResult, error) {
 	// parse each supplier's array and group by hotel id
 	// result: key: hotel id, value: hotel data from all suppliers
 	hotelGroups, err := m.groupHotelsById(suppliers)
@@ -90,20 +98,41 @@ func (m *MappingEngine) Transform(suppliers SupplierData) (json.RawMessage, erro
 		return nil, fmt.Errorf("failed to group hotels: %w", err)
 	}
 
-	// transform each hotel group
+	// transform each hotel group, in id order so the output is the same on every run
+	hotelIds := make([]string, 0, len(hotelGroups))
+	for hotelId := range hotelGroups {
+		hotelIds = append(hotelIds, hotelId)
+	}
+	sort.Strings(hotelIds)
+
+	transformed := &TransformResult{}
+	if m.provenance {
+		transformed.Provenance = make(map[string]HotelProvenance, len(hotelIds))
+	}
+	if m.conflicts {
+		transformed.Conflicts = []Conflict{}
+	}
+
 	var results []map[string]interface{}
-	for hotelId, hotelSuppliers := range hotelGroups {
+	var unmatched []unmatchedTerm
+	for _, hotelId := range hotelIds {
+		hotelSuppliers := hotelGroups[hotelId]
 		result := make(map[string]interface{})
-		err := m.processMapping("", m.config, hotelSuppliers, result)
-
-		// skip hotel if mapping cannot be processed
-		if err != nil {
-			fmt.Printf("Failed to process hotel %s: %v\n", hotelId, err)
-			continue
+		recorder := &hotelRecorder{hotelId: hotelId}
+		if m.provenance {
+			recorder.provenance = make(HotelProvenance, len(m.plan))
 		}
+		m.processPlan(hotelSuppliers, result, recorder)
 
 		results = append(results, result)
+		if recorder.provenance != nil {
+			transformed.Provenance[hotelId] = recorder.provenance
+		}
+		transformed.Conflicts = append(transformed.Conflicts, recorder.conflicts...)
+		unmatched = append(unmatched, recorder.unmatched...)
+		transformed.CoercionFailures = append(transformed.CoercionFailures, recorder.coercionFailures...)
 	}
+	transformed.Unmatched = countUnmatched(unmatched)
 
 	// marshal the results array
 	output, err := json.Marshal(results)
@@ -111,7 +140,8 @@ func (m *MappingEngine) Transform(suppliers SupplierData) (json.RawMessage, erro
 		return nil, fmt.Errorf("failed to marshal results: %w", err)
 	}
 
-	return json.RawMessage(output), nil
+	transformed.Hotels = json.RawMessage(output)
+	return transformed, nil
 }
 
 // groupHotelsById processes supplier arrays and groups hotels by their Ids
@@ -119,12 +149,12 @@ func (m *MappingEngine) groupHotelsById(suppliers SupplierData) (map[string]Hote
 	hotelGroups := make(map[string]HotelSupplierData)
 
 	// id field mappings for each supplier
-	idFieldMappings := m.extractIdFieldMapping()
+	idFieldMappings := m.idPaths
 
 	// process each supplier
 	for supplierKey, supplierData := range suppliers {
 		// parse the JSON array
-		supplierArray := gjson.Get(string(supplierData), "@this")
+		supplierArray := gjson.ParseBytes(supplierData)
 		if !supplierArray.IsArray() {
 			return nil, fmt.Errorf("supplier response %s is not an array", supplierKey)
 		}
@@ -140,7 +170,7 @@ func (m *MappingEngine) groupHotelsById(suppliers SupplierData) (map[string]Hote
 			// Extract hotel id
 			hotelId := gjson.Get(hotelItem.Raw, idField)
 			if !hotelId.Exists() || hotelId.String() == "" {
-				fmt.Printf("Warning: No id found for hotel in supplier %s\n", supplierKey)
+				log.Printf("warning: no id found for hotel in supplier %s", supplierKey) // stderr, stdout may carry a JSON report
 				continue
 			}
 
@@ -159,6 +189,38 @@ func (m *MappingEngine) groupHotelsById(suppliers SupplierData) (map[string]Hote
 	return hotelGroups, nil
 }
 
+// IdFieldMapping returns the id field path of every supplier, keyed by supplier name
+func (m *MappingEngine) IdFieldMapping() map[string]string {
+	return m.extractIdFieldMapping()
+}
+
+// Suppliers returns the sorted names of all suppliers referenced anywhere in the mapping
+func (m *MappingEngine) Suppliers() []string {
+	seenSupplier := make(map[string]bool)
+	collectSuppliers(map[string]interface{}(m.config), seenSupplier)
+
+	suppliers := make([]string, 0, len(seenSupplier))
+	for name := range seenSupplier {
+		suppliers = append(suppliers, name)
+	}
+	sort.Strings(suppliers)
+	return suppliers
+}
+
+// collectSuppliers recursively gathers supplier names from "src::" keys
+func collectSuppliers(config interface{}, seenSupplier map[string]bool) {
+	switch v := config.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if strings.HasPrefix(key, dataSupplierPrefix) {
+				seenSupplier[strings.TrimPrefix(key, dataSupplierPrefix)] = true
+				continue
+			}
+			collectSuppliers(value, seenSupplier)
+		}
+	}
+}
+
 func (m *MappingEngine) extractIdFieldMapping() map[string]string {
 	// extracts from existing mapping.json:
 	// "id": {
@@ -168,48 +230,34 @@ func (m *MappingEngine) extractIdFieldMapping() map[string]string {
 	// }
 
 	idMappings := make(map[string]string)
-	idConfig := m.config["id"] // gets the "id" mapping, update key if result id field changes
+	idConfig, _ := m.config["id"].(map[string]interface{}) // gets the "id" mapping, update key if result id field changes
 
-	for key, value := range idConfig.(map[string]interface{}) {
+	for key, value := range idConfig {
 		if strings.HasPrefix(key, dataSupplierPrefix) {
 			supplierName := strings.TrimPrefix(key, dataSupplierPrefix) // "supplier_1", "supplier_2", etc.
-			idMappings[supplierName] = value.(string)                   // "Id", "id", "hotel_id"
+			if path, ok := value.(string); ok && path != "" {
+				idMappings[supplierName] = path // "Id", "id", "hotel_id"
+			}
 		}
 	}
 
 	return idMappings
 }
 
-// processMapping recursively processes the mapping configuration
-func (m *MappingEngine) processMapping(currentPath string, config interface{}, suppliers HotelSupplierData, result map[string]interface{}) error {
+// processPlan executes every field plan for a single hotel
+func (m *MappingEngine) processPlan(suppliers HotelSupplierData, result map[string]interface{}, recorder *hotelRecorder) {
+	records := make(map[string]*record, len(suppliers)) // key: supplier name
+	for supplierName, supplierData := range suppliers {
+		records[supplierName] = newRecord(supplierData)
+	}
 
-	switch v := config.(type) {
-	case map[string]interface{}:
-		if m.isLeafMapping(v) {
-			value, err := m.processLeafMapping(v, suppliers)
-			if err != nil {
-				return err
-			}
-			m.setNestedValue(result, currentPath, value)
-		} else { // recursive processing for nested objects
-			for key, value := range v {
-				newPath := key
-				if currentPath != "" {
-					newPath = currentPath + "." + key
-				}
-				if err := m.processMapping(newPath, value, suppliers, result); err != nil {
-					return err
-				}
-			}
-		}
-	case MappingConfig: // unfortunately golang doesn't support type aliasing in type switches
-		return m.processMapping(currentPath, map[string]interface{}(v), suppliers, result)
+	for _, field := range m.plan {
+		m.setNestedValue(result, field.path, m.processField(field, records, recorder))
 	}
-	return nil
 }
 
 // isLeafMapping checks if a mapping object contains supplier definitions
-func (*MappingEngine) isLeafMapping(mapping map[string]interface{}) bool {
+func isLeafMapping(mapping map[string]interface{}) bool {
 	for key := range mapping {
 		if strings.HasPrefix(key, dataSupplierPrefix) {
 			return true
@@ -218,95 +266,59 @@ func (*MappingEngine) isLeafMapping(mapping map[string]interface{}) bool {
 	return false
 }
 
-// processLeafMapping processes a leaf mapping with supplier paths
-func (m *MappingEngine) processLeafMapping(mapping map[string]interface{}, suppliers HotelSupplierData) (interface{}, error) {
-	fieldMapping := m.parseFieldMapping(mapping)
-
+// processField extracts the values of a field from all suppliers and merges them
+func (m *MappingEngine) processField(field *fieldPlan, records map[string]*record, recorder *hotelRecorder) interface{} {
 	// extract values from all suppliers
-	values := m.extractValuesFromSuppliers(fieldMapping.SupplierPaths, suppliers)
-	// apply actions if specified
-	if len(fieldMapping.Actions) > 0 {
-		return m.applyActions(values, fieldMapping.Actions, fieldMapping)
+	candidates := m.extractValuesFromSuppliers(field.sources, records)
+	if field.valueType != "" {
+		candidates = m.coerceCandidates(field, candidates, recorder)
+	}
+	var extracted []candidate
+	if recorder.provenance != nil || m.conflicts {
+		extracted = slices.Clone(candidates)
 	}
 
-	return m.selectBestValue(values), nil
-}
+	merged, result := m.applyActions(candidates, field, recorder)
 
-// parseFieldMapping converts raw mapping to FieldMapping struct
-func (*MappingEngine) parseFieldMapping(mapping map[string]interface{}) FieldMapping {
-	fieldMapping := FieldMapping{
-		SupplierPaths:           make(map[string]interface{}),
-		Actions:                 []string{},
-		ObjectArrayFieldMapping: make(map[string][]string),
+	if recorder.provenance != nil && result != nil {
+		var trace FieldProvenance
+		m.traceField(&trace, field, extracted, candidates, merged)
+		recorder.provenance[field.name] = trace
 	}
-
-	for key, value := range mapping {
-		if strings.HasPrefix(key, dataSupplierPrefix) {
-			fieldMapping.SupplierPaths[key] = value
-		} else if key == "actions" {
-			if actions, ok := value.([]interface{}); ok {
-				for _, action := range actions {
-					if actionStr, ok := action.(string); ok {
-						fieldMapping.Actions = append(fieldMapping.Actions, actionStr)
-					}
-				}
-			}
-		} else if key == "field_mapping" {
-			if fieldMapConfig, ok := value.(map[string]interface{}); ok {
-				for fieldName, possibleFields := range fieldMapConfig {
-					if fieldList, ok := possibleFields.([]interface{}); ok {
-						var stringList []string
-						for _, field := range fieldList {
-							if fieldStr, ok := field.(string); ok {
-								stringList = append(stringList, fieldStr)
-							}
-						}
-						fieldMapping.ObjectArrayFieldMapping[fieldName] = stringList
-					}
-				}
-			}
+	if m.conflicts {
+		if conflict, found := m.detectConflict(field, extracted, candidates, merged); found {
+			conflict.HotelId = recorder.hotelId
+			recorder.conflicts = append(recorder.conflicts, conflict)
 		}
-
 	}
-
-	return fieldMapping
+	return result
 }
 
-// extractValuesFromSuppliers extracts values from all suppliers using JSONPath or templates
-func (m *MappingEngine) extractValuesFromSuppliers(supplierPaths map[string]interface{}, suppliers HotelSupplierData) map[string]interface{} {
-	values := make(map[string]interface{})
+// extractValuesFromSuppliers extracts values from all suppliers using JSONPath or templates, in source order
+func (m *MappingEngine) extractValuesFromSuppliers(sources []sourcePlan, records map[string]*record) []candidate {
+	candidates := make([]candidate, 0, len(sources))
 
-	for supplierKey, pathOrTemplate := range supplierPaths {
-		supplierName := strings.TrimPrefix(supplierKey, dataSupplierPrefix)
-		if supplierData, hasSupplier := suppliers[supplierName]; hasSupplier {
-			value := m.extractValue(supplierData, pathOrTemplate)
+	for _, source := range sources {
+		if supplierRecord, hasSupplier := records[source.supplier]; hasSupplier {
+			value := m.extractValue(supplierRecord, source)
 			if value != nil {
-				values[supplierKey] = value
+				candidates = append(candidates, candidate{supplier: source.supplier, path: source.raw, weight: source.weight, value: value})
 			}
 		}
 	}
 
-	return values
+	return candidates
 }
 
 // extractValue extracts a value using JSONPath or template
-func (m *MappingEngine) extractValue(supplierData json.RawMessage, pathOrTemplate interface{}) interface{} {
-	if pathOrTemplate == nil {
-		return nil
-	}
-
-	pathStr, ok := pathOrTemplate.(string)
-	if !ok {
-		return nil
-	}
-
+func (m *MappingEngine) extractValue(supplierRecord *record, source sourcePlan) interface{} {
 	// handle template strings (e.g., "{{Address}}, {{PostalCode}}")
-	if m.isTemplate(pathStr) {
-		return m.processTemplate(supplierData, pathStr)
+	if source.template != nil {
+		return m.processTemplate(supplierRecord, source.template)
 	}
 
 	// handle regular JSONPath
-	result := gjson.Get(string(supplierData), pathStr)
+	result := supplierRecord.get(source.path)
 	if !result.Exists() {
 		return nil
 	}
@@ -342,149 +354,75 @@ func (*MappingEngine) isTemplate(str string) bool {
 	return strings.Contains(str, "{{") && strings.Contains(str, "}}")
 }
 
-// processTemplate processes template strings like "{{Address}}, {{PostalCode}}"
-func (*MappingEngine) processTemplate(supplierData json.RawMessage, template string) interface{} {
-	// find all template variables
-	re := regexp.MustCompile(`\{\{([^}]+)\}\}`)
-	matches := re.FindAllStringSubmatch(template, -1)
-
-	result := template
-	for _, match := range matches {
-		if len(match) >= 2 {
-			fieldName := match[1]
-			placeholder := match[0]
-
-			// extract value for this field
-			value := gjson.Get(string(supplierData), fieldName)
-			if value.Exists() {
-				result = strings.ReplaceAll(result, placeholder, strings.TrimSpace(value.String()))
-			} else {
-				result = strings.ReplaceAll(result, placeholder, "")
-			}
-		}
-	}
-
-	// clean up extra commas and spaces
-	result = strings.TrimSpace(result)
-	result = regexp.MustCompile(`\s*,\s*,\s*`).ReplaceAllString(result, ", ")
-	result = regexp.MustCompile(`^,\s*|,\s*$`).ReplaceAllString(result, "")
-
-	return result
-}
+var (
+	repeatedCommas = regexp.MustCompile(`\s*,\s*,\s*`)
+	danglingComma  = regexp.MustCompile(`^,\s*|,\s*$`)
+)
 
-// selectBestValue chooses the best value from available suppliers
-// for non-strings: returns the first non-nil value
-// for strings: delegates to selectStringBestValue for custom logic
-// NOTE: can be configurable
-func (m *MappingEngine) selectBestValue(values map[string]interface{}) interface{} {
-	for _, value := range values {
-		if value == nil {
+// processTemplate renders a parsed template like "{{Address}}, {{PostalCode}}"
+func (*MappingEngine) processTemplate(supplierRecord *record, parsed template) interface{} {
+	var result strings.Builder
+	for _, segment := range parsed {
+		if segment.path == nil {
+			result.WriteString(segment.literal)
 			continue
 		}
 
-		switch v := value.(type) {
-		case string:
-			if strings.TrimSpace(v) != "" {
-				return m.selectStringBestValue(values)
-			}
-		default:
-			return v // first non-nil, non-string value
+		// extract value for this field, missing fields render as empty
+		if value := supplierRecord.get(*segment.path); value.Exists() {
+			result.WriteString(strings.TrimSpace(value.String()))
 		}
 	}
-	return nil
-}
 
-// selectStringBestValue chooses the best value from available suppliers
-func (*MappingEngine) selectStringBestValue(values map[string]interface{}) interface{} {
-	longestStr := ""
-	for _, value := range values {
-		// default behavior if string: return longest non-empty string
-		if str, ok := value.(string); ok && strings.TrimSpace(str) != "" {
-			trimmedVal := strings.TrimSpace(str)
-			if len(trimmedVal) > len(longestStr) {
-				longestStr = trimmedVal
-			}
-		}
-	}
+	// clean up extra commas and spaces
+	rendered := strings.TrimSpace(result.String())
+	rendered = repeatedCommas.ReplaceAllString(rendered, ", ")
+	rendered = danglingComma.ReplaceAllString(rendered, "")
 
-	return longestStr
+	return rendered
 }
 
-// applyActions applies processing actions to values
-func (m *MappingEngine) applyActions(values map[string]interface{}, actions []string, fieldMapping FieldMapping) (interface{}, error) {
-	var result interface{}
-
-	// apply each action in sequence
-	for _, action := range actions {
-		action = strings.TrimSpace(action)
-		switch action {
-		case "normalize_general_amenities":
-			result = m.normalizeGeneralAmenities(values)
-		case "normalize_room_amenities":
-			result = m.normalizeRoomAmenities(values)
-		case "merge_image_arrays":
-			result = m.mergeObjectArrays(values, fieldMapping.ObjectArrayFieldMapping, "link") // "link" is the unique identifier for the object array
-		case "to_lowercase":
-			result = m.toLowerCase(result)
+// applyActions applies processing actions to the candidates and merges them
+// value actions listed before the merge action run on every candidate, later ones on the merged value
+func (m *MappingEngine) applyActions(candidates []candidate, field *fieldPlan, recorder *hotelRecorder) (merged interface{}, result interface{}) {
+	for _, action := range field.actions[:field.mergeIndex] {
+		for i := range candidates {
+			candidates[i].value = action.value(m, candidates[i].value, candidates[i].supplier, recorder)
 		}
 	}
 
-	return result, nil
-}
-
-// normalizeAmenities normalizes amenities by mapping known variants to standard names
-func (m *MappingEngine) normalizeGeneralAmenities(values map[string]interface{}) interface{} {
-	return m.normalizeAmenities(values, generalAmenity)
-}
+	merged = m.mergeCandidates(candidates, field, recorder)
 
-// normalizeRoomAmenities normalizes amenities by mapping known variants to standard names
-func (m *MappingEngine) normalizeRoomAmenities(values map[string]interface{}) interface{} {
-	return m.normalizeAmenities(values, roomAmenity)
-}
-
-// normalizeAmenities normalizes amenities by mapping known variants to standard names
-func (m *MappingEngine) normalizeAmenities(values map[string]interface{}, amenityMap map[string]string) interface{} {
-	seenValue := make(map[string]bool)
-
-	for _, value := range values {
-		if arr, ok := value.([]interface{}); ok && len(arr) > 0 {
-			merged := m.mergeLists(values)
-			lowered := m.toLowerCase(merged)
-
-			for _, v := range lowered.([]interface{}) {
-				if str, ok := v.(string); ok {
-					if norm, exists := amenityMap[str]; exists {
-						seenValue[norm] = true
-					} else {
-						// NOTE: discard value if not in map of amenities
-					}
-				}
-			}
+	result = merged
+	if field.mergeIndex < len(field.actions) {
+		for _, action := range field.actions[field.mergeIndex+1:] {
+			result = action.value(m, result, "", recorder)
 		}
-		break // break after first non-empty as we already merged all lists
 	}
+	return merged, result
+}
 
-	deduplicated := []string{}
-	for k := range seenValue {
-		deduplicated = append(deduplicated, k)
+// mergeCandidates combines the candidates with the field's merge action, or its strategy without one
+func (m *MappingEngine) mergeCandidates(candidates []candidate, field *fieldPlan, recorder *hotelRecorder) interface{} {
+	if field.mergeIndex < len(field.actions) {
+		return field.actions[field.mergeIndex].merge(m, candidates, field, recorder)
 	}
-
-	return deduplicated
+	return field.strategy(m, candidates)
 }
 
 // mergeObjectArrays merges arrays of objects from multiple suppliers
-func (m *MappingEngine) mergeObjectArrays(values map[string]interface{}, fieldMapping map[string][]string, uniqueIdentifier string) interface{} {
+func (m *MappingEngine) mergeObjectArrays(candidates []candidate, fieldMapping map[string][]string, uniqueIdentifier string) interface{} {
 	var uniqueObjects []map[string]interface{}
 	seenObject := make(map[string]bool) // track by link to avoid duplicates
 
 	// process each supplier
-	for _, value := range values {
-		if value == nil {
+	for _, c := range candidates {
+		if c.value == nil {
 			continue
 		}
 
 		// handle array of objects
-		if arr, ok := value.([]interface{}); ok {
+		if arr, ok := c.value.([]interface{}); ok {
 			for _, objInterface := range arr {
 				if obj, ok := objInterface.(map[string]interface{}); ok {
 					normalizedObject := m.normalizeObject(obj, fieldMapping)
@@ -524,16 +462,15 @@ func (*MappingEngine) normalizeObject(obj map[string]interface{}, fieldMapping m
 }
 
 // setNestedValue sets a value at a nested path in the result map
-func (*MappingEngine) setNestedValue(result map[string]interface{}, path string, value interface{}) {
-	if value == nil || path == "" {
+func (*MappingEngine) setNestedValue(result map[string]interface{}, path []string, value interface{}) {
+	if value == nil || len(path) == 0 {
 		return
 	}
 
-	parts := strings.Split(path, ".")
 	current := result
 
 	// navigate to the parent of the target
-	for _, part := range parts[:len(parts)-1] {
+	for _, part := range path[:len(path)-1] {
 		if _, exists := current[part]; !exists {
 			current[part] = make(map[string]interface{})
 		}
@@ -544,16 +481,16 @@ func (*MappingEngine) setNestedValue(result map[string]interface{}, path string,
 		}
 	}
 
-	finalKey := parts[len(parts)-1]
+	finalKey := path[len(path)-1]
 	current[finalKey] = value
 }
 
-// mergeLists merges multiple lists into one, removing duplicates
-func (*MappingEngine) mergeLists(values map[string]interface{}) interface{} {
+// mergeLists merges the lists of all candidates into one, removing duplicates
+func (*MappingEngine) mergeLists(candidates []candidate) interface{} {
 	var allValues []interface{}
-	for _, value := range values {
-		if value != nil {
-			allValues = append(allValues, value)
+	for _, c := range candidates {
+		if c.value != nil {
+			allValues = append(allValues, c.value)
 		}
 	}
 
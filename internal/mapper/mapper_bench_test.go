@@ -0,0 +1,59 @@
+package mapper_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
+)
+
+const benchmarkHotels = 100_000
+
+// syntheticSuppliers builds n hotels per supplier in the shapes of testdata/source_*.json
+func syntheticSuppliers(n int) mapper.SupplierData {
+	var source1, source2, source3 strings.Builder
+	for i := range n {
+		sep := ","
+		if i == 0 {
+			sep = "["
+		}
+		fmt.Fprintf(&source1, `%s{"Id":"h%d","DestinationId":%d,"Name":"Hotel %d","Latitude":1.26%d,"Longitude":103.82%d,"Address":" %d Sentosa Gateway ","City":"Singapore","Country":"SG","PostalCode":"0982%d","Description":"  Hotel %d on the coastline.","Facilities":["Pool","BusinessCenter","WiFi ","DryCleaning"," Breakfast","Aircon"]}`,
+			sep, i, i%500, i, i, i, i, i%100, i)
+		fmt.Fprintf(&source2, `%s{"id":"h%d","destination":%d,"name":"Hotel %d","lat":1.26%d,"lng":103.82%d,"address":"%d Sentosa Gateway, 0982%d","info":"Located at the western tip of Sentosa, hotel %d.","amenities":["Aircon","Tv","Coffee machine","Tub"],"images":{"rooms":[{"url":"https://img.example.com/%d/1.jpg","description":"Double room"}],"amenities":[{"url":"https://img.example.com/%d/0.jpg","description":"RWS"}]}}`,
+			sep, i, i%500, i, i, i, i, i%100, i, i, i)
+		fmt.Fprintf(&source3, `%s{"hotel_id":"h%d","destination_id":%d,"hotel_name":"Hotel %d","location":{"address":"%d Sentosa Gateway, 0982%d","country":"Singapore"},"details":"Surrounded by tropical gardens, hotel %d.","amenities":{"general":["outdoor pool","business center"],"room":["tv","kettle"]},"images":{"rooms":[{"link":"https://img.example.com/%d/1.jpg","caption":"Double room"}],"site":[{"link":"https://img.example.com/%d/2.jpg","caption":"Front"}]},"booking_conditions":["No pets."]}`,
+			sep, i, i%500, i, i, i%100, i, i, i)
+	}
+	source1.WriteString("]")
+	source2.WriteString("]")
+	source3.WriteString("]")
+
+	return mapper.SupplierData{
+		"source_1": json.RawMessage(source1.String()),
+		"source_2": json.RawMessage(source2.String()),
+		"source_3": json.RawMessage(source3.String()),
+	}
+}
+
+func BenchmarkTransform_100kHotels(b *testing.B) {
+	mappingConfig, err := os.ReadFile("../../testdata/mapping.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	engine, err := mapper.NewMappingEngine(mappingConfig)
+	if err != nil {
+		b.Fatal(err)
+	}
+	suppliers := syntheticSuppliers(benchmarkHotels)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := engine.Transform(suppliers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/ptrciafae/hotels-merge/internal/mapper"
@@ -12,6 +14,20 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// amenitiesVocabulary is the "vocabularies" entry used by the amenity tests
+const amenitiesVocabulary = `"vocabularies": {
+	"amenities": [
+		{"term": "business center", "category": "general"},
+		{"term": "gym", "category": "general"},
+		{"term": "outdoor pool", "category": "general", "synonyms": ["pool"]},
+		{"term": "wifi", "category": "general"},
+		{"term": "bar", "category": "general"},
+		{"term": "aircon", "category": "room"},
+		{"term": "tv", "category": "room"},
+		{"term": "bathtub", "category": "room", "synonyms": ["tub"]}
+	]
+}`
+
 func TestMappingEngine_BasicTransformation(t *testing.T) {
 	// sample mapping configuration
 	mappingConfig := `{
@@ -141,6 +157,7 @@ func TestMappingEngine_DifferentDataTypes(t *testing.T) {
 }
 func TestMappingEngine_GeneralAmenities(t *testing.T) {
 	mappingConfig := `{
+		` + amenitiesVocabulary + `,
 		"id": {
 			"src::source_1": "Id",
 			"src::source_2": "id",
@@ -151,7 +168,7 @@ func TestMappingEngine_GeneralAmenities(t *testing.T) {
 				"src::source_1": "Facilities",
 				"src::source_2": null,
 				"src::source_3": "amenities.general",
-				"actions": ["normalize_general_amenities"]
+				"actions": ["normalize_vocabulary:amenities:general"]
 			}
 		}
 	}`
@@ -174,15 +191,13 @@ func TestMappingEngine_GeneralAmenities(t *testing.T) {
 	amenities := transformed[0]["amenities"].(map[string]interface{})
 	general := amenities["general"].([]interface{})
 
-	assert.Len(t, general, 4) // deduplicated length
-	assert.Contains(t, general, "wifi")
-	assert.Contains(t, general, "business center")
-	assert.Contains(t, general, "outdoor pool")
-	assert.Contains(t, general, "gym") // deduplicated and lowercased
+	// deduplicated and lowercased, in the order of the vocabulary
+	assert.Equal(t, []interface{}{"business center", "gym", "outdoor pool", "wifi"}, general)
 }
 
 func TestMappingEngine_RoomAmenities(t *testing.T) {
 	mappingConfig := `{
+		` + amenitiesVocabulary + `,
 		"id": {
 			"src::source_1": "Id",
 			"src::source_2": "id",
@@ -193,7 +208,7 @@ func TestMappingEngine_RoomAmenities(t *testing.T) {
 				"src::source_1": "Facilities",
 				"src::source_2": "amenities",
 				"src::source_3": "amenities.room",
-				"actions": ["normalize_room_amenities"]
+				"actions": ["normalize_vocabulary:amenities:room"]
 			}
 		}
 	}`
@@ -217,10 +232,8 @@ func TestMappingEngine_RoomAmenities(t *testing.T) {
 	amenities := transformed[0]["amenities"].(map[string]interface{})
 	room := amenities["room"].([]interface{})
 
-	assert.Len(t, room, 3) // deduplicated length
-	assert.Contains(t, room, "aircon")
-	assert.Contains(t, room, "tv")
-	assert.Contains(t, room, "bathtub") // deduplicated and lowercased
+	// deduplicated and lowercased, in the order of the vocabulary
+	assert.Equal(t, []interface{}{"aircon", "tv", "bathtub"}, room)
 }
 
 // Test function to verify the image merging works correctly
@@ -377,3 +390,997 @@ func TestMappingEngine_FromSampleFiles(t *testing.T) {
 	// NOTE: intentionally failing the test to output the result
 	assert.NotEqual(t, result, result) // TODO: implement actual assertion logic
 }
+
+func TestNewMappingEngine_ValidationErrors(t *testing.T) {
+	mappingConfig := `{
+		"name": {
+			"src::source_1": "Name",
+			"src::source_2": 42,
+			"actions": ["to_lowercase", "shout"]
+		},
+		"location": {
+			"address": {
+				"src::source_1": "{{Address}, {{PostalCode}}",
+				"city": {
+					"src::source_1": "City"
+				}
+			},
+			"actions": ["to_lowercase"]
+		},
+		"images": {
+			"rooms": {
+				"src::source_1": "images.rooms",
+				"field_mapping": {
+					"link": ["url"]
+				}
+			}
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.Error(t, err)
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/id", Reason: "missing id mapping"},
+		{Pointer: "/images/rooms/field_mapping", Reason: "field_mapping is only used by the merge_image_arrays action"},
+		{Pointer: "/location/actions", Reason: `reserved key "actions" used in a mapping without src:: keys`},
+		{Pointer: "/location/address/city", Reason: "nested field in a mapping with src:: keys, a mapping is either a leaf or a branch"},
+		{Pointer: "/location/address/src::source_1", Reason: `malformed template "{{Address}, {{PostalCode}}": nested braces in placeholder`},
+		{Pointer: "/name/src::source_2", Reason: "supplier path must be a string or null"},
+		{Pointer: "/name/actions/1", Reason: `unknown action "shout"`},
+	}, validationErrs)
+}
+
+func TestNewMappingEngine_InvalidIdMapping(t *testing.T) {
+	mappingConfig := `{
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": null
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/id/src::source_2", Reason: "id path must be a non-empty string"},
+	}, validationErrs)
+}
+
+func TestMappingEngine_MergeStrategies(t *testing.T) {
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[{"Id": "123", "Name": "Hotel A", "Stars": 3, "Rating": "4", "Country": "SG", "Tags": ["pool", "bar"]}]`),
+		"source_2": json.RawMessage(`[{"id": "123", "name": "The Hotel A", "stars": 5, "rating": 4, "country": "Singapore", "tags": ["bar", "gym"]}]`),
+		"source_3": json.RawMessage(`[{"hotel_id": "123", "hotel_name": "", "stars": 4, "rating": 4, "country": "Singapore", "tags": []}]`),
+	}
+
+	tests := []struct {
+		name     string
+		leaf     string
+		expected interface{}
+	}{
+		{"longest", `"src::source_1": "Name", "src::source_2": "name", "strategy": "longest"`, "The Hotel A"},
+		{"shortest", `"src::source_1": "Name", "src::source_2": "name", "src::source_3": "hotel_name", "strategy": "shortest"`, "Hotel A"},
+		{"priority", `"src::source_1": "Name", "src::source_2": "name", "src::source_3": "hotel_name", "strategy": "priority", "priority": ["source_3", "source_2"]`, "The Hotel A"},
+		{"majority", `"src::source_1": "Country", "src::source_2": "country", "src::source_3": "country", "strategy": "majority"`, "Singapore"},
+		{"majority counts 4 and \"4\" apart", `"src::source_1": "Rating", "src::source_2": "rating", "src::source_3": "rating", "strategy": "majority"`, float64(4)},
+		{"first non empty", `"src::source_1": "Country", "src::source_2": "country", "strategy": "first_non_empty"`, "SG"},
+		{"max", `"src::source_1": "Stars", "src::source_2": "stars", "src::source_3": "stars", "strategy": "max"`, float64(5)},
+		{"min", `"src::source_1": "Stars", "src::source_2": "stars", "src::source_3": "stars", "strategy": "min"`, float64(3)},
+		{"union", `"src::source_1": "Tags", "src::source_2": "tags", "src::source_3": "tags", "strategy": "union"`, []interface{}{"pool", "bar", "gym"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mappingConfig := fmt.Sprintf(`{
+				"id": {
+					"src::source_1": "Id",
+					"src::source_2": "id",
+					"src::source_3": "hotel_id"
+				},
+				"value": {%s}
+			}`, tt.leaf)
+
+			engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+			require.NoError(t, err)
+
+			result, err := engine.Transform(sources)
+			require.NoError(t, err)
+
+			var transformed []map[string]interface{}
+			require.NoError(t, json.Unmarshal(result, &transformed))
+			assert.Equal(t, tt.expected, transformed[0]["value"])
+		})
+	}
+}
+
+func TestMappingEngine_DefaultStrategyIsDeterministic(t *testing.T) {
+	mappingConfig := `{
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id",
+			"src::source_3": "hotel_id"
+		},
+		"destination_id": {
+			"src::source_1": "DestinationId",
+			"src::source_2": "destination",
+			"src::source_3": "destination_id"
+		}
+	}`
+
+	engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[{"Id": "123", "DestinationId": 1}]`),
+		"source_2": json.RawMessage(`[{"id": "123", "destination": 2}]`),
+		"source_3": json.RawMessage(`[{"hotel_id": "123", "destination_id": 3}]`),
+	}
+
+	// without a strategy non-string values come from the first supplier in alphabetical order
+	for range 20 {
+		result, err := engine.Transform(sources)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"id": "123", "destination_id": 1}]`, string(result))
+	}
+}
+
+func TestNewMappingEngine_InvalidStrategies(t *testing.T) {
+	mappingConfig := `{
+		` + amenitiesVocabulary + `,
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id"
+		},
+		"name": {
+			"src::source_1": "Name",
+			"strategy": "loudest"
+		},
+		"country": {
+			"src::source_1": "Country",
+			"src::source_2": "country",
+			"strategy": "priority",
+			"priority": ["source_2", "source_3"]
+		},
+		"city": {
+			"src::source_1": "City",
+			"strategy": "priority"
+		},
+		"stars": {
+			"src::source_1": "Stars",
+			"priority": ["source_1"]
+		},
+		"amenities": {
+			"src::source_1": "Facilities",
+			"strategy": "union",
+			"actions": ["normalize_vocabulary:amenities", "select_longest"]
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/amenities/actions/1", Reason: `action "select_longest" merges supplier values, "normalize_vocabulary:amenities" already does`},
+		{Pointer: "/amenities/strategy", Reason: `strategy is ignored, action "normalize_vocabulary:amenities" merges supplier values`},
+		{Pointer: "/city", Reason: `strategy "priority" requires a priority list of suppliers`},
+		{Pointer: "/country/priority/1", Reason: "source_3 is not a supplier of this mapping"},
+		{Pointer: "/name/strategy", Reason: `unknown strategy "loudest"`},
+		{Pointer: "/stars/priority", Reason: `priority is only used by strategy "priority"`},
+	}, validationErrs)
+}
+
+func TestMappingEngine_SupplierWeights(t *testing.T) {
+	mappingConfig := `{
+		"supplier_weights": {
+			"source_2": 2,
+			"source_3": 2
+		},
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id",
+			"src::source_3": "hotel_id"
+		},
+		"name": {
+			"src::source_1": "Name",
+			"src::source_2": "name",
+			"src::source_3": "hotel_name",
+			"strategy": "first_non_empty"
+		},
+		"lat": {
+			"src::source_1": "Latitude",
+			"src::source_2": "lat",
+			"src::source_3": "lat",
+			"weights": { "source_1": 5 },
+			"strategy": "first_non_empty"
+		},
+		"country": {
+			"src::source_1": "Country",
+			"src::source_2": "country",
+			"src::source_3": "country",
+			"weights": { "source_1": 5 },
+			"strategy": "majority"
+		}
+	}`
+
+	engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[{"Id": "123", "Name": "Hotel A", "Latitude": 1.5, "Country": "SG"}]`),
+		"source_2": json.RawMessage(`[{"id": "123", "name": "Hotel B", "lat": 2.5, "country": "Singapore"}]`),
+		"source_3": json.RawMessage(`[{"hotel_id": "123", "hotel_name": "Hotel C", "lat": 3.5, "country": "Singapore"}]`),
+	}
+
+	result, err := engine.Transform(sources)
+	require.NoError(t, err)
+
+	// name: source_2 and source_3 share the highest weight, the tie goes to the first name alphabetically
+	// lat: the field override makes source_1 the most trusted
+	// country: source_1 outweighs the two other suppliers together
+	assert.JSONEq(t, `[{"id": "123", "name": "Hotel B", "lat": 1.5, "country": "SG"}]`, string(result))
+}
+
+func TestNewMappingEngine_InvalidWeights(t *testing.T) {
+	mappingConfig := `{
+		"supplier_weights": {
+			"source_1": 0,
+			"source_9": 1
+		},
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id"
+		},
+		"name": {
+			"src::source_1": "Name",
+			"weights": { "source_2": "high" }
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/name/weights/source_2", Reason: "weight must be a positive number"},
+		{Pointer: "/name/weights/source_2", Reason: "source_2 is not a supplier of this mapping"},
+		{Pointer: "/supplier_weights/source_1", Reason: "weight must be a positive number"},
+		{Pointer: "/supplier_weights/source_9", Reason: "source_9 is not a supplier of this mapping"},
+	}, validationErrs)
+}
+
+func TestMappingEngine_Provenance(t *testing.T) {
+	mappingConfig := `{
+		` + amenitiesVocabulary + `,
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id"
+		},
+		"name": {
+			"src::source_1": "Name",
+			"src::source_2": "name"
+		},
+		"amenities": {
+			"src::source_1": "Facilities",
+			"src::source_2": "amenities",
+			"actions": ["normalize_vocabulary:amenities:general"]
+		}
+	}`
+
+	engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+	engine.EnableProvenance()
+
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[{"Id": "123", "Name": "Hotel A", "Facilities": ["Pool", "WiFi"]}]`),
+		"source_2": json.RawMessage(`[{"id": "123", "name": "The Hotel A", "amenities": ["wifi", "gym", "bar"]}]`),
+	}
+
+	result, err := engine.TransformDetailed(sources)
+	require.NoError(t, err)
+
+	provenance := result.Provenance["123"]
+	assert.Equal(t, mapper.FieldProvenance{
+		Supplier:   "source_1",
+		Path:       "Id",
+		Candidates: []mapper.Candidate{{Supplier: "source_2", Path: "id", Value: "123"}},
+		Strategy:   "default",
+	}, provenance["id"])
+	assert.Equal(t, mapper.FieldProvenance{
+		Supplier:   "source_2",
+		Path:       "name",
+		Candidates: []mapper.Candidate{{Supplier: "source_1", Path: "Name", Value: "Hotel A"}},
+		Strategy:   "default",
+	}, provenance["name"])
+
+	amenities := provenance["amenities"]
+	assert.Empty(t, amenities.Supplier)
+	assert.Empty(t, amenities.Candidates)
+	assert.Equal(t, []string{"normalize_vocabulary:amenities:general"}, amenities.Actions)
+	itemSuppliers := map[interface{}]string{}
+	for _, item := range amenities.Items {
+		itemSuppliers[item.Value] = item.Supplier
+	}
+	assert.Equal(t, map[interface{}]string{"outdoor pool": "source_1", "wifi": "source_1", "gym": "source_2", "bar": "source_2"}, itemSuppliers)
+
+	// provenance is only recorded when enabled
+	withoutProvenance, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+	result, err = withoutProvenance.TransformDetailed(sources)
+	require.NoError(t, err)
+	assert.Nil(t, result.Provenance)
+}
+
+func TestMappingEngine_ConflictDetection(t *testing.T) {
+	mappingConfig := `{
+		"conflict_tolerance": {
+			"numeric_epsilon": 0.01,
+			"string_similarity": 0.8
+		},
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id"
+		},
+		"name": {
+			"src::source_1": "Name",
+			"src::source_2": "name"
+		},
+		"city": {
+			"src::source_1": "City",
+			"src::source_2": "city",
+			"tolerance": { "string_similarity": 0.85 }
+		},
+		"country": {
+			"src::source_1": "Country",
+			"src::source_2": "country"
+		},
+		"lat": {
+			"src::source_1": "Latitude",
+			"src::source_2": "lat"
+		},
+		"lng": {
+			"src::source_1": "Longitude",
+			"src::source_2": "lng",
+			"tolerance": { "numeric_epsilon": 1 }
+		}
+	}`
+
+	engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+	engine.EnableConflictDetection()
+
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[{"Id": "123", "Name": "Hotel  A!", "City": "Malmö City Hotel", "Country": "SG", "Latitude": 1.3001, "Longitude": 103.5}]`),
+		"source_2": json.RawMessage(`[{"id": "123", "name": "hotel a", "city": "Malmo City Hotel", "country": "Singapore", "lat": 1.4, "lng": 103.9}]`),
+	}
+
+	result, err := engine.TransformDetailed(sources)
+	require.NoError(t, err)
+
+	// names only differ in case and punctuation, cities in one accented letter, lng is within the field's own tolerance
+	assert.Equal(t, []mapper.Conflict{
+		{
+			HotelId:    "123",
+			Field:      "country",
+			Values:     map[string]interface{}{"source_1": "SG", "source_2": "Singapore"},
+			Resolution: "default",
+			Value:      "Singapore",
+			Supplier:   "source_2",
+		},
+		{
+			HotelId:    "123",
+			Field:      "lat",
+			Values:     map[string]interface{}{"source_1": 1.3001, "source_2": 1.4},
+			Resolution: "default",
+			Value:      1.3001,
+			Supplier:   "source_1",
+		},
+	}, result.Conflicts)
+}
+
+func TestNewMappingEngine_InvalidTolerance(t *testing.T) {
+	mappingConfig := `{
+		"conflict_tolerance": {
+			"numeric_epsilon": -1,
+			"levenshtein": 2
+		},
+		"id": {
+			"src::source_1": "Id"
+		},
+		"name": {
+			"src::source_1": "Name",
+			"tolerance": { "string_similarity": 1.5 }
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/conflict_tolerance/levenshtein", Reason: `unknown tolerance "levenshtein"`},
+		{Pointer: "/conflict_tolerance/numeric_epsilon", Reason: "tolerance must be a non-negative number"},
+		{Pointer: "/name/tolerance/string_similarity", Reason: "string_similarity must be between 0 and 1"},
+	}, validationErrs)
+}
+
+func TestNewMappingEngine_InvalidVocabularies(t *testing.T) {
+	mappingConfig := `{
+		"vocabularies": {
+			"amenities": [
+				{"term": "wifi", "category": "general", "synonyms": ["wi-fi"]},
+				{"term": "wireless", "synonyms": ["Wi-Fi"]},
+				{"term": ""}
+			],
+			"countries": "SG",
+			"facilities": {"order": "random", "max_distance": -1, "stop_words": ["free wifi"], "terms": []}
+		},
+		"id": {
+			"src::source_1": "Id"
+		},
+		"general": {
+			"src::source_1": "Facilities",
+			"actions": ["normalize_vocabulary:amenities:spa"]
+		},
+		"room": {
+			"src::source_1": "Facilities",
+			"actions": ["normalize_vocabulary:rooms"]
+		},
+		"country": {
+			"src::source_1": "Country",
+			"actions": ["normalize_country:iso"]
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/vocabularies/amenities/1", Reason: `"Wi-Fi" already maps to "wifi"`},
+		{Pointer: "/vocabularies/amenities/2/term", Reason: "term must be a non-empty string"},
+		{Pointer: "/vocabularies/countries", Reason: "vocabulary must be an array of terms"},
+		{Pointer: "/vocabularies/facilities/max_distance", Reason: "max_distance must be a non-negative integer"},
+		{Pointer: "/vocabularies/facilities/order", Reason: "order must be one of vocabulary, alphabetical, first_seen"},
+		{Pointer: "/vocabularies/facilities/stop_words/0", Reason: "stop word must be a single word"},
+		{Pointer: "/country/actions/0", Reason: `unknown country output "iso", expected one of name, alpha2, alpha3`},
+		{Pointer: "/general/actions/0", Reason: `vocabulary "amenities" has no category "spa"`},
+		{Pointer: "/room/actions/0", Reason: `unknown vocabulary "rooms"`},
+	}, validationErrs)
+}
+
+func TestMappingEngine_AmenityMatching(t *testing.T) {
+	mappingConfig := `{
+		"vocabularies": {
+			"amenities": {
+				"max_distance": 1,
+				"stop_words": ["free"],
+				"terms": [
+					{"term": "business center", "category": "general"},
+					{"term": "outdoor pool", "category": "general", "synonyms": ["pool"]},
+					{"term": "wifi", "category": "general"},
+					{"term": "bar", "category": "general"},
+					{"term": "tv", "category": "room"},
+					{"term": "crèche", "category": "general"}
+				]
+			}
+		},
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id"
+		},
+		"amenities": {
+			"src::source_1": "Facilities",
+			"src::source_2": "amenities",
+			"actions": ["normalize_vocabulary:amenities:general"]
+		}
+	}`
+
+	engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[
+			{"Id": "1", "Facilities": ["WiFi ", "BusinessCenter", "Outdor Pool", "TV", "Sauna"]},
+			{"Id": "2", "Facilities": ["Wi-Fi", "bra"]}
+		]`),
+		"source_2": json.RawMessage(`[
+			{"id": "1", "amenities": ["free wifi", "Business Centre", "sauna"]},
+			{"id": "2", "amenities": ["Free Wi-Fi", "Business-Centre", "Creche"]}
+		]`),
+	}
+
+	result, err := engine.TransformDetailed(sources)
+	require.NoError(t, err)
+
+	var transformed []map[string]interface{}
+	require.NoError(t, json.Unmarshal(result.Hotels, &transformed))
+
+	// "TV" is in the room category, "bra" is too short to be corrected to "bar", "Creche" is one letter from "crèche"
+	assert.Equal(t, []interface{}{"business center", "outdoor pool", "wifi"}, transformed[0]["amenities"])
+	assert.Equal(t, []interface{}{"business center", "wifi", "crèche"}, transformed[1]["amenities"])
+	assert.Equal(t, []mapper.UnmatchedTerm{
+		{Vocabulary: "amenities", Supplier: "source_1", Term: "Sauna", Count: 1},
+		{Vocabulary: "amenities", Supplier: "source_1", Term: "bra", Count: 1},
+		{Vocabulary: "amenities", Supplier: "source_2", Term: "sauna", Count: 1},
+	}, result.Unmatched)
+}
+
+func TestMappingEngine_UnmatchedTerms(t *testing.T) {
+	mappingConfig := `{
+		` + amenitiesVocabulary + `,
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id"
+		},
+		"amenities": {
+			"general": {
+				"src::source_1": "Facilities",
+				"actions": ["normalize_vocabulary:amenities:general"]
+			},
+			"room": {
+				"src::source_1": "Facilities",
+				"src::source_2": "amenities",
+				"actions": ["normalize_vocabulary:amenities:room"]
+			}
+		}
+	}`
+
+	engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+
+	// source_1 values are seen by both fields, but only counted once per hotel
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[
+			{"Id": "1", "Facilities": ["hot tub", "gym", "tv"]},
+			{"Id": "2", "Facilities": ["hot tub", "spa"]}
+		]`),
+		"source_2": json.RawMessage(`[{"id": "1", "amenities": ["hot tub", "tub"]}]`),
+	}
+
+	result, err := engine.TransformDetailed(sources)
+	require.NoError(t, err)
+
+	assert.Equal(t, []mapper.UnmatchedTerm{
+		{Vocabulary: "amenities", Supplier: "source_1", Term: "hot tub", Count: 2},
+		{Vocabulary: "amenities", Supplier: "source_1", Term: "spa", Count: 1},
+		{Vocabulary: "amenities", Supplier: "source_2", Term: "hot tub", Count: 1},
+	}, result.Unmatched)
+}
+
+func TestMappingEngine_AmenityOrder(t *testing.T) {
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[{"Id": "1", "Facilities": ["wifi", "Pool", "bar"]}]`),
+		"source_2": json.RawMessage(`[{"id": "1", "amenities": ["gym", "WiFi", "business center"]}]`),
+	}
+
+	tests := []struct {
+		order    string
+		expected []interface{}
+	}{
+		{order: "vocabulary", expected: []interface{}{"business center", "gym", "outdoor pool", "wifi", "bar"}},
+		{order: "alphabetical", expected: []interface{}{"bar", "business center", "gym", "outdoor pool", "wifi"}},
+		// source_2 comes first, it has the higher weight
+		{order: "first_seen", expected: []interface{}{"gym", "wifi", "business center", "outdoor pool", "bar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			mappingConfig := `{
+				"vocabularies": {
+					"amenities": {
+						"order": "` + tt.order + `",
+						"terms": [
+							{"term": "business center"},
+							{"term": "gym"},
+							{"term": "outdoor pool", "synonyms": ["pool"]},
+							{"term": "wifi"},
+							{"term": "bar"}
+						]
+					}
+				},
+				"id": {
+					"src::source_1": "Id",
+					"src::source_2": "id"
+				},
+				"amenities": {
+					"src::source_1": "Facilities",
+					"src::source_2": "amenities",
+					"weights": {"source_2": 2},
+					"actions": ["normalize_vocabulary:amenities"]
+				}
+			}`
+
+			engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+			require.NoError(t, err)
+
+			// the order is stable across runs
+			for range 5 {
+				result, err := engine.Transform(sources)
+				require.NoError(t, err)
+
+				var transformed []map[string]interface{}
+				require.NoError(t, json.Unmarshal(result, &transformed))
+				assert.Equal(t, tt.expected, transformed[0]["amenities"])
+			}
+		})
+	}
+}
+
+func TestMappingEngine_NormalizeCountry(t *testing.T) {
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[
+			{"Id": "1", "Country": "SG"},
+			{"Id": "2", "Country": "gbr"},
+			{"Id": "3", "Country": "Ivory Coast"},
+			{"Id": "4", "Country": " Atlantis "}
+		]`),
+		"source_2": json.RawMessage(`[
+			{"id": "1", "country": "Singapore"},
+			{"id": "2", "country": "U.K."},
+			{"id": "3", "country": "Cote d'Ivoire"}
+		]`),
+	}
+
+	tests := []struct {
+		action   string
+		expected []interface{}
+	}{
+		{action: "normalize_country", expected: []interface{}{"Singapore", "United Kingdom", "Côte d'Ivoire", "Atlantis"}},
+		{action: "normalize_country:alpha2", expected: []interface{}{"SG", "GB", "CI", "Atlantis"}},
+		{action: "normalize_country:alpha3", expected: []interface{}{"SGP", "GBR", "CIV", "Atlantis"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			mappingConfig := `{
+				"id": {
+					"src::source_1": "Id",
+					"src::source_2": "id"
+				},
+				"country": {
+					"src::source_1": "Country",
+					"src::source_2": "country",
+					"weights": {"source_2": 2},
+					"actions": ["` + tt.action + `"]
+				}
+			}`
+
+			engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+			require.NoError(t, err)
+			engine.EnableConflictDetection()
+
+			result, err := engine.TransformDetailed(sources)
+			require.NoError(t, err)
+
+			var transformed []map[string]interface{}
+			require.NoError(t, json.Unmarshal(result.Hotels, &transformed))
+			for i, hotel := range transformed {
+				assert.Equal(t, tt.expected[i], hotel["country"])
+			}
+
+			// the suppliers agree once normalized, the unknown country is kept trimmed and reported
+			assert.Empty(t, result.Conflicts)
+			assert.Equal(t, []mapper.UnmatchedTerm{
+				{Vocabulary: "countries", Supplier: "source_1", Term: "Atlantis", Count: 1},
+			}, result.Unmatched)
+		})
+	}
+}
+
+func TestMappingEngine_MergeAddress(t *testing.T) {
+	mappingConfig := `{
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id",
+			"src::source_3": "hotel_id"
+		},
+		"address": {
+			"src::source_1": "{{Address}}, {{PostalCode}}",
+			"src::source_2": "address",
+			"src::source_3": "location.address",
+			"weights": {"source_1": 1.5},
+			"actions": ["merge_address"]
+		},
+		"address_parts": {
+			"src::source_1": "{{Address}}, {{PostalCode}}",
+			"src::source_2": "address",
+			"src::source_3": "location.address",
+			"weights": {"source_1": 1.5},
+			"actions": ["merge_address:parts"]
+		}
+	}`
+
+	engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+	engine.EnableConflictDetection()
+
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[
+			{"Id": "1", "Address": " 1 Nanson Road", "PostalCode": "238909"},
+			{"Id": "2", "Address": "160-0023, SHINJUKU-KU, 6-6-2 NISHI-SHINJUKU, JAPAN", "PostalCode": "160-0023"},
+			{"Id": "3", "Address": "10 Bayfront Ave", "PostalCode": "018956"},
+			{"Id": "4", "Address": " 8 Sentosa Gateway, Beach Villas ", "PostalCode": "098269"}
+		]`),
+		"source_2": json.RawMessage(`[
+			{"id": "2", "address": "6-6-2 Nishi-Shinjuku,  Shinjuku-ku , 160-0023"},
+			{"id": "3", "address": "1 Harbourfront Walk, Singapore 098585"},
+			{"id": "5", "address": "30 Beach Road, Jersey, Singapore 189763"}
+		]`),
+		"source_3": json.RawMessage(`[
+			{"hotel_id": "1", "location": {"address": "1 Nanson Rd, Singapore 238909"}},
+			{"hotel_id": "3", "location": {"address": "1 HARBOURFRONT WALK, VivoCity"}}
+		]`),
+	}
+
+	result, err := engine.TransformDetailed(sources)
+	require.NoError(t, err)
+
+	var transformed []map[string]interface{}
+	require.NoError(t, json.Unmarshal(result.Hotels, &transformed))
+
+	// equivalent addresses complete each other
+	assert.Equal(t, "1 Nanson Road, Singapore 238909", transformed[0]["address"])
+	assert.Equal(t, map[string]interface{}{
+		"street":      "1 Nanson Road",
+		"postal_code": "238909",
+		"city":        "Singapore",
+	}, transformed[0]["address_parts"])
+	assert.Equal(t, "6-6-2 Nishi-Shinjuku, Shinjuku-Ku 160-0023, Japan", transformed[1]["address"])
+
+	// two suppliers agree on another address than the most trusted one
+	assert.Equal(t, "1 Harbourfront Walk, VivoCity, Singapore 098585", transformed[2]["address"])
+	assert.Equal(t, "8 Sentosa Gateway, Beach Villas, 098269", transformed[3]["address"])
+
+	// only the end of an address is the country, a district named like one is not
+	assert.Equal(t, map[string]interface{}{
+		"street":      "30 Beach Road",
+		"building":    "Jersey",
+		"postal_code": "189763",
+		"city":        "Singapore",
+	}, transformed[4]["address_parts"])
+
+	require.Len(t, result.Conflicts, 2)
+	for _, conflict := range result.Conflicts {
+		assert.Equal(t, "3", conflict.HotelId)
+		assert.Len(t, conflict.Values, 3)
+	}
+	assert.Equal(t, "merge_address", result.Conflicts[0].Resolution)
+	assert.Equal(t, "merge_address:parts", result.Conflicts[1].Resolution)
+}
+
+func TestNewMappingEngine_InvalidAddressOutput(t *testing.T) {
+	mappingConfig := `{
+		"id": {
+			"src::source_1": "Id"
+		},
+		"address": {
+			"src::source_1": "Address",
+			"actions": ["merge_address:json"]
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/address/actions/0", Reason: `unknown address output "json", expected one of text, parts`},
+	}, validationErrs)
+}
+
+func TestMappingEngine_MergeCoordinates(t *testing.T) {
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[
+			{"Id": "1", "Latitude": 1.2847, "Longitude": 103.8610},
+			{"Id": "2", "Latitude": 0, "Longitude": 0},
+			{"Id": "3", "Latitude": 1.3, "Longitude": 103.8},
+			{"Id": "4", "Latitude": "10.0", "Longitude": "40.0"}
+		]`),
+		"source_2": json.RawMessage(`[
+			{"id": "1", "lat": 1.2848, "lng": 103.8611},
+			{"id": "2", "lat": 35.6926, "lng": 139.6910},
+			{"id": "3", "lat": 1.31, "lng": 103.81},
+			{"id": "4", "lat": 10.0002, "lng": 40.0002}
+		]`),
+		"source_3": json.RawMessage(`[
+			{"hotel_id": "1", "geo": {"lat": 103.8610, "lng": 1.2847}},
+			{"hotel_id": "2", "geo": {"lat": 35.6930, "lng": 139.6912}},
+			{"hotel_id": "3", "geo": {"lat": 48.8566, "lng": 2.3522}},
+			{"hotel_id": "4", "geo": {"lat": 40.0, "lng": 10.0}}
+		]`),
+	}
+
+	tests := []struct {
+		consensus string
+		expected  [][2]float64
+		supplier  string // of the coordinates of hotel 3, empty for a median
+	}{
+		{consensus: "median", expected: [][2]float64{{1.28475, 103.86105}, {35.6928, 139.6911}, {1.305, 103.805}, {10.0001, 40.0001}}},
+		{consensus: "trusted", expected: [][2]float64{{1.2847, 103.8610}, {35.6926, 139.6910}, {1.3, 103.8}, {10.0, 40.0}}, supplier: "source_1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.consensus, func(t *testing.T) {
+			mappingConfig := `{
+				"conflict_tolerance": {"distance_km": 2},
+				"id": {
+					"src::source_1": "Id",
+					"src::source_2": "id",
+					"src::source_3": "hotel_id"
+				},
+				"lat": {
+					"src::source_1": "{lat:Latitude,lng:Longitude}",
+					"src::source_2": "{lat:lat,lng:lng}",
+					"src::source_3": "geo",
+					"actions": ["merge_coordinates:lat:` + tt.consensus + `"]
+				},
+				"lng": {
+					"src::source_1": "{lat:Latitude,lng:Longitude}",
+					"src::source_2": "{lat:lat,lng:lng}",
+					"src::source_3": "geo",
+					"actions": ["merge_coordinates:lng:` + tt.consensus + `"]
+				}
+			}`
+
+			engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+			require.NoError(t, err)
+			engine.EnableConflictDetection()
+
+			result, err := engine.TransformDetailed(sources)
+			require.NoError(t, err)
+
+			var transformed []map[string]interface{}
+			require.NoError(t, json.Unmarshal(result.Hotels, &transformed))
+			for i, hotel := range transformed {
+				assert.InDelta(t, tt.expected[i][0], hotel["lat"], 1e-9, "hotel %s", hotel["id"])
+				assert.InDelta(t, tt.expected[i][1], hotel["lng"], 1e-9, "hotel %s", hotel["id"])
+			}
+
+			// one conflict per hotel, recorded on the lat field only
+			require.Len(t, result.Conflicts, 4)
+			rejected := make(map[string]map[string]string)
+			for _, conflict := range result.Conflicts {
+				assert.Equal(t, "lat", conflict.Field)
+				rejected[conflict.HotelId] = conflict.Rejected
+				if conflict.HotelId == "3" {
+					assert.Equal(t, tt.supplier, conflict.Supplier)
+				}
+			}
+			assert.Equal(t, map[string]string{"source_3": "latitude and longitude are swapped"}, rejected["1"])
+			assert.Equal(t, map[string]string{"source_1": "coordinates are (0, 0), a placeholder for unknown coordinates"}, rejected["2"])
+			assert.Equal(t, []string{"source_3"}, slices.Collect(maps.Keys(rejected["3"])))
+			assert.Contains(t, rejected["3"]["source_3"], "km away from the other suppliers")
+			assert.Equal(t, map[string]string{"source_3": "latitude and longitude are swapped"}, rejected["4"])
+		})
+	}
+}
+
+func TestNewMappingEngine_InvalidCoordinates(t *testing.T) {
+	mappingConfig := `{
+		"id": {
+			"src::source_1": "Id"
+		},
+		"lat": {
+			"src::source_1": "{lat:Latitude,lng:Longitude}",
+			"actions": ["merge_coordinates:latitude"]
+		},
+		"lng": {
+			"src::source_1": "{lat:Latitude,lng:Longitude}",
+			"actions": ["merge_coordinates:lng:mean"],
+			"tolerance": {"distance_km": -1}
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/lat/actions/0", Reason: `unknown coordinate "latitude", expected one of lat, lng`},
+		{Pointer: "/lng/actions/0", Reason: `unknown consensus "mean", expected one of median, trusted`},
+		{Pointer: "/lng/tolerance/distance_km", Reason: "tolerance must be a non-negative number"},
+	}, validationErrs)
+}
+
+func TestMappingEngine_TypeCoercion(t *testing.T) {
+	mappingConfig := `{
+		"id": {
+			"src::source_1": "Id",
+			"src::source_2": "id"
+		},
+		"destination_id": {
+			"src::source_1": "DestinationId",
+			"src::source_2": "destination",
+			"type": "int"
+		},
+		"rating": {
+			"src::source_1": "Rating",
+			"type": "float"
+		},
+		"name": {
+			"src::source_1": "Name",
+			"type": "string"
+		},
+		"breakfast": {
+			"src::source_1": "Breakfast",
+			"type": "bool"
+		},
+		"booking_conditions": {
+			"src::source_2": "conditions",
+			"type": "string[]"
+		}
+	}`
+
+	engine, err := mapper.NewMappingEngine([]byte(mappingConfig))
+	require.NoError(t, err)
+
+	sources := mapper.SupplierData{
+		"source_1": json.RawMessage(`[
+			{"Id": "1", "DestinationId": "5432", "Rating": "4.5", "Name": 1901, "Breakfast": "yes"},
+			{"Id": "2", "DestinationId": "near the beach", "Rating": 4, "Name": " Hilton ", "Breakfast": 0}
+		]`),
+		"source_2": json.RawMessage(`[
+			{"id": "1", "destination": 5432.0, "conditions": "No pets"},
+			{"id": "2", "destination": "", "conditions": [" No smoking", 12, {"note": "free"}, ""]}
+		]`),
+	}
+
+	result, err := engine.TransformDetailed(sources)
+	require.NoError(t, err)
+
+	var transformed []map[string]interface{}
+	require.NoError(t, json.Unmarshal(result.Hotels, &transformed))
+
+	assert.Equal(t, map[string]interface{}{
+		"id":                 "1",
+		"destination_id":     float64(5432),
+		"rating":             4.5,
+		"name":               "1901",
+		"breakfast":          true,
+		"booking_conditions": []interface{}{"No pets"},
+	}, transformed[0])
+
+	// the malformed values are left out, not the hotel
+	assert.Equal(t, map[string]interface{}{
+		"id":                 "2",
+		"rating":             float64(4),
+		"name":               "Hilton",
+		"breakfast":          false,
+		"booking_conditions": []interface{}{"No smoking", "12"},
+	}, transformed[1])
+
+	assert.Equal(t, []mapper.CoercionFailure{
+		{HotelId: "2", Field: "booking_conditions", Supplier: "source_2", Value: map[string]interface{}{"note": "free"}, Type: "string[]", Reason: "expected a string, got an object"},
+		{HotelId: "2", Field: "destination_id", Supplier: "source_1", Value: "near the beach", Type: "int", Reason: `"near the beach" is not a number`},
+	}, result.CoercionFailures)
+}
+
+func TestNewMappingEngine_InvalidType(t *testing.T) {
+	mappingConfig := `{
+		"id": {
+			"src::source_1": "Id"
+		},
+		"rating": {
+			"src::source_1": "Rating",
+			"type": "double"
+		},
+		"tags": {
+			"src::source_1": "Tags",
+			"type": ["string"]
+		}
+	}`
+
+	_, err := mapper.NewMappingEngine([]byte(mappingConfig))
+
+	var validationErrs mapper.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, mapper.ValidationErrors{
+		{Pointer: "/rating/type", Reason: `unknown type "double", expected one of int, float, string, bool, string[]`},
+		{Pointer: "/tags/type", Reason: `unknown type ["string"], expected one of int, float, string, bool, string[]`},
+	}, validationErrs)
+}
@@ -0,0 +1,331 @@
+package mapper
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// fieldPlan is the compiled form of a leaf mapping, built once by NewMappingEngine
+// and executed for every hotel
+type fieldPlan struct {
+	path               []string     // output path, e.g. ["location", "lat"]
+	name               string       // dotted output path, e.g. "location.lat"
+	sources            []sourcePlan // ranked by weight then supplier name, or in "priority" order
+	actions            []actionPlan // applied in order
+	mergeIndex         int          // position of the merge action in actions, len(actions) if there is none
+	strategy           strategyFunc // selects the value when no merge action is listed
+	strategyName       string
+	valueType          string              // type the supplier values are converted to, "" to keep them as read
+	tolerance          Tolerance           // how far supplier values may differ before they conflict
+	objectFieldMapping map[string][]string // key: field name, value: possible supplier field names, used for merging object arrays
+}
+
+// sourcePlan describes where a supplier's value for a field is read from
+type sourcePlan struct {
+	key      string   // mapping key, e.g. "src::acme"
+	raw      string   // JSON path or template as written in the mapping
+	supplier string   // supplier name, e.g. "acme"
+	weight   float64  // trust in the supplier for this field
+	path     jsonPath // unused for templates
+	template template // parsed template, nil for plain JSON paths
+}
+
+// template is a parsed template string such as "{{Address}}, {{PostalCode}}"
+type template []templateSegment
+
+// templateSegment is either literal text or a placeholder holding a JSON path
+type templateSegment struct {
+	literal string
+	path    *jsonPath
+}
+
+// jsonPath is a pre-parsed gjson path
+// simple dotted paths are split into the top level key and the rest, so a record is only scanned once
+// for all its top level keys, other paths (wildcards, queries, modifiers...) are handed to gjson as is
+type jsonPath struct {
+	raw    string
+	simple bool
+	head   string // top level key of a simple path
+	tail   string // remainder of a simple path after head, "" if none
+}
+
+func parseJSONPath(path string) jsonPath {
+	parsed := jsonPath{raw: path}
+	if path == "" || strings.ContainsAny(path, `*?#@|\{}[]()!=<>%~,:`) {
+		return parsed
+	}
+
+	parsed.simple = true
+	parsed.head, parsed.tail, _ = strings.Cut(path, ".")
+	return parsed
+}
+
+// record is a supplier's data for a single hotel, its top level keys are indexed on first use
+type record struct {
+	raw    json.RawMessage
+	fields map[string]gjson.Result
+}
+
+func newRecord(raw json.RawMessage) *record {
+	return &record{raw: raw}
+}
+
+// get resolves a pre-parsed path against the record
+func (r *record) get(path jsonPath) gjson.Result {
+	if !path.simple {
+		return gjson.GetBytes(r.raw, path.raw)
+	}
+
+	if r.fields == nil {
+		r.fields = make(map[string]gjson.Result)
+		gjson.ParseBytes(r.raw).ForEach(func(key, value gjson.Result) bool {
+			if _, exists := r.fields[key.Str]; !exists { // gjson returns the first match on duplicated keys
+				r.fields[key.Str] = value
+			}
+			return true
+		})
+	}
+
+	value := r.fields[path.head]
+	if path.tail == "" || !value.Exists() {
+		return value
+	}
+	return value.Get(path.tail)
+}
+
+// mergeFunc combines the candidates of all suppliers into the value of a field
+// findings such as unmatched terms go to the recorder, which is nil when they must not be recorded
+type mergeFunc func(m *MappingEngine, candidates []candidate, field *fieldPlan, recorder *hotelRecorder) interface{}
+
+// valueFunc transforms a single value of a supplier, supplier is empty for the merged value
+// findings go to the recorder, which is nil when they must not be recorded
+type valueFunc func(m *MappingEngine, value interface{}, supplier string, recorder *hotelRecorder) interface{}
+
+// actionPlan is an action resolved from its name in the mapping config
+// merge actions replace the field's strategy, value actions run on every candidate before the merge,
+// or on the merged value when listed after a merge action
+type actionPlan struct {
+	name  string
+	merge mergeFunc
+	value valueFunc
+
+	// equivalent tells whether two supplier values agree for conflict detection, set by the merge actions
+	// that know when different values mean the same thing, fields merged by other actions are not checked
+	equivalent func(a, b interface{}) bool
+}
+
+// actionRegistry maps the action names usable in mapping.json to their implementation
+var actionRegistry = map[string]actionPlan{
+	"select_longest": {merge: func(m *MappingEngine, candidates []candidate, _ *fieldPlan, _ *hotelRecorder) interface{} {
+		return m.selectStringBestValue(candidates)
+	}},
+	"merge_image_arrays": {merge: func(m *MappingEngine, candidates []candidate, field *fieldPlan, _ *hotelRecorder) interface{} {
+		return m.mergeObjectArrays(candidates, field.objectFieldMapping, "link") // "link" is the unique identifier for the object array
+	}},
+	"to_lowercase": {value: func(m *MappingEngine, value interface{}, _ string, _ *hotelRecorder) interface{} {
+		return m.toLowerCase(value)
+	}},
+}
+
+// actionBuilders build the actions taking parameters, written as "name:param[:param...]" in mapping.json
+var actionBuilders = map[string]func(params []string, vocabularies map[string]*vocabulary) (actionPlan, error){
+	"normalize_vocabulary": normalizeVocabularyAction,
+	"normalize_country":    normalizeCountryAction,
+	"merge_address":        mergeAddressAction,
+	"merge_coordinates":    mergeCoordinatesAction,
+}
+
+// resolveAction looks an action up by the name used in mapping.json
+func resolveAction(name string, vocabularies map[string]*vocabulary) (actionPlan, error) {
+	base, rawParams, hasParams := strings.Cut(name, ":")
+	if build, exists := actionBuilders[base]; exists {
+		var params []string
+		if hasParams {
+			params = strings.Split(rawParams, ":")
+		}
+		action, err := build(params, vocabularies)
+		action.name = name
+		return action, err
+	}
+
+	action, exists := actionRegistry[name]
+	if !exists {
+		return actionPlan{}, fmt.Errorf("unknown action %q", name)
+	}
+	action.name = name
+	return action, nil
+}
+
+// compile turns the validated mapping config into field plans, ordered by output path
+func (m *MappingEngine) compile() []*fieldPlan {
+	var plan []*fieldPlan
+	for _, key := range sortedKeys(m.config) {
+		if child, ok := m.config[key].(map[string]interface{}); ok && !isReservedTopLevelKey(key) {
+			m.compileNode([]string{key}, child, &plan)
+		}
+	}
+	return plan
+}
+
+// compileNode recursively compiles a branch or leaf mapping
+func (m *MappingEngine) compileNode(path []string, mapping map[string]interface{}, plan *[]*fieldPlan) {
+	if isLeafMapping(mapping) {
+		*plan = append(*plan, m.compileLeaf(path, mapping))
+		return
+	}
+
+	for _, key := range sortedKeys(mapping) {
+		if child, ok := mapping[key].(map[string]interface{}); ok {
+			m.compileNode(append(slices.Clone(path), key), child, plan)
+		}
+	}
+}
+
+// compileLeaf parses the supplier paths, actions and field mapping of a leaf mapping
+func (m *MappingEngine) compileLeaf(path []string, mapping map[string]interface{}) *fieldPlan {
+	field := &fieldPlan{
+		path:               path,
+		name:               strings.Join(path, "."),
+		objectFieldMapping: make(map[string][]string),
+	}
+
+	for _, key := range sortedKeys(mapping) {
+		switch value := mapping[key].(type) {
+		case string:
+			if !strings.HasPrefix(key, dataSupplierPrefix) {
+				continue
+			}
+			source := sourcePlan{
+				key:      key,
+				supplier: strings.TrimPrefix(key, dataSupplierPrefix),
+				raw:      value,
+			}
+			source.weight = m.supplierWeight(source.supplier, mapping)
+			if m.isTemplate(value) {
+				source.template = parseTemplate(value)
+			} else {
+				source.path = parseJSONPath(value)
+			}
+			field.sources = append(field.sources, source)
+		case []interface{}:
+			if key != actionsKey {
+				continue
+			}
+			for _, action := range value {
+				action, _ := resolveAction(strings.TrimSpace(action.(string)), m.vocabularies) // validated by NewMappingEngine
+				field.actions = append(field.actions, action)
+			}
+		case map[string]interface{}:
+			if key != fieldMappingKey {
+				continue
+			}
+			for fieldName, possibleFields := range value {
+				for _, supplierField := range possibleFields.([]interface{}) {
+					field.objectFieldMapping[fieldName] = append(field.objectFieldMapping[fieldName], supplierField.(string))
+				}
+			}
+		}
+	}
+
+	// most trusted suppliers first, ties in alphabetical order
+	slices.SortStableFunc(field.sources, func(a, b sourcePlan) int {
+		return cmp.Compare(b.weight, a.weight)
+	})
+
+	field.tolerance = parseTolerance(mapping[toleranceKey], m.tolerance)
+	field.valueType, _ = mapping[typeKey].(string)
+
+	field.mergeIndex = slices.IndexFunc(field.actions, func(action actionPlan) bool { return action.merge != nil })
+	if field.mergeIndex == -1 {
+		field.mergeIndex = len(field.actions)
+	}
+
+	field.strategy, field.strategyName = (*MappingEngine).selectBestValue, strategyDefault
+	if name, ok := mapping[strategyKey].(string); ok {
+		field.strategy, field.strategyName = strategyRegistry[name], name
+	}
+	if priority, ok := mapping[priorityKey].([]interface{}); ok {
+		field.sources = prioritizeSources(field.sources, priority)
+	}
+
+	return field
+}
+
+// supplierWeight returns the trust in a supplier for a leaf mapping:
+// the leaf's "weights" override, else the "supplier_weights" default, else 1
+func (m *MappingEngine) supplierWeight(supplier string, mapping map[string]interface{}) float64 {
+	if weight, ok := parseWeights(mapping[weightsKey])[supplier]; ok {
+		return weight
+	}
+	if weight, ok := m.weights[supplier]; ok {
+		return weight
+	}
+	return defaultWeight
+}
+
+// parseWeights reads a validated weights object, nil if there is none
+func parseWeights(value interface{}) map[string]float64 {
+	rawWeights, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	weights := make(map[string]float64, len(rawWeights))
+	for supplier, weight := range rawWeights {
+		weights[supplier] = weight.(float64)
+	}
+	return weights
+}
+
+// prioritizeSources orders sources as listed in priority, suppliers not listed keep their order at the end
+func prioritizeSources(sources []sourcePlan, priority []interface{}) []sourcePlan {
+	rank := make(map[string]int, len(priority))
+	for i, supplier := range priority {
+		rank[supplier.(string)] = i
+	}
+
+	ordered := slices.Clone(sources)
+	slices.SortStableFunc(ordered, func(a, b sourcePlan) int {
+		rankA, listedA := rank[a.supplier]
+		rankB, listedB := rank[b.supplier]
+		switch {
+		case listedA && listedB:
+			return rankA - rankB
+		case listedA:
+			return -1
+		case listedB:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return ordered
+}
+
+// parseTemplate splits a validated template into literal and placeholder segments
+func parseTemplate(str string) template {
+	var parsed template
+	rest := str
+	for {
+		open := strings.Index(rest, "{{")
+		if open == -1 {
+			if rest != "" {
+				parsed = append(parsed, templateSegment{literal: rest})
+			}
+			return parsed
+		}
+		if open > 0 {
+			parsed = append(parsed, templateSegment{literal: rest[:open]})
+		}
+
+		closing := open + strings.Index(rest[open:], "}}")
+		path := parseJSONPath(rest[open+2 : closing])
+		parsed = append(parsed, templateSegment{path: &path})
+		rest = rest[closing+2:]
+	}
+}
@@ -0,0 +1,140 @@
+package mapper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HotelProvenance records where the fields of a merged hotel came from
+// key: dotted output path, e.g. "location.lat"
+type HotelProvenance map[string]FieldProvenance
+
+// FieldProvenance records how the value of a single output field was chosen
+type FieldProvenance struct {
+	Supplier   string           `json:"supplier,omitempty"` // supplier of the value, empty when merged from several suppliers
+	Path       string           `json:"path,omitempty"`     // JSON path or template the value was read from
+	Items      []ItemProvenance `json:"items,omitempty"`    // supplier of every item of a value merged from several suppliers
+	Candidates []Candidate      `json:"candidates"`         // values of the suppliers that lost, or contributed no item
+	Strategy   string           `json:"strategy,omitempty"` // empty when an action merged the values
+	Actions    []string         `json:"actions,omitempty"`
+}
+
+// ItemProvenance is the supplier an item of a merged list came from
+type ItemProvenance struct {
+	Value    interface{} `json:"value"`
+	Supplier string      `json:"supplier"`
+	Path     string      `json:"path"`
+}
+
+// Candidate is a value a supplier provided for a field, before any action ran
+type Candidate struct {
+	Supplier string      `json:"supplier"`
+	Path     string      `json:"path"`
+	Value    interface{} `json:"value"`
+}
+
+// EnableProvenance makes TransformDetailed record the provenance of every output field
+// it must be called before the engine is used, recording costs an allocation per field and hotel
+func (m *MappingEngine) EnableProvenance() {
+	m.provenance = true
+}
+
+// traceField records the supplier of a merged value, or of every item of a merged list
+// extracted holds the candidates as read from the suppliers, candidates the same after the value actions
+func (m *MappingEngine) traceField(trace *FieldProvenance, field *fieldPlan, extracted, candidates []candidate, merged interface{}) {
+	trace.Candidates = []Candidate{}
+	for _, action := range field.actions {
+		trace.Actions = append(trace.Actions, action.name)
+	}
+	if field.mergeIndex == len(field.actions) {
+		trace.Strategy = field.strategyName
+	}
+
+	// a value taken as is from one supplier
+	winner := -1
+	for i, c := range candidates {
+		if sameValue(c.value, merged) {
+			winner = i
+			break
+		}
+	}
+	if winner != -1 {
+		trace.Supplier, trace.Path = candidates[winner].supplier, candidates[winner].path
+		for i, c := range extracted {
+			if i != winner {
+				trace.Candidates = append(trace.Candidates, Candidate{Supplier: c.supplier, Path: c.path, Value: c.value})
+			}
+		}
+		return
+	}
+
+	// a list merged from several suppliers: an item comes from the first supplier which alone produces it,
+	// as merging keeps the first occurrence of an item in candidate order
+	items, isList := listItems(merged)
+	if !isList {
+		return
+	}
+
+	itemSource := make(map[string]int, len(items)) // key: item, value: candidate index
+	for i, c := range candidates {
+		single, _ := listItems(m.mergeCandidates([]candidate{c}, field, nil))
+		for _, item := range single {
+			if _, seen := itemSource[valueKey(item)]; !seen {
+				itemSource[valueKey(item)] = i
+			}
+		}
+	}
+
+	contributed := make(map[int]bool)
+	for _, item := range items {
+		source, found := itemSource[valueKey(item)]
+		if !found {
+			continue
+		}
+		contributed[source] = true
+		trace.Items = append(trace.Items, ItemProvenance{Value: item, Supplier: candidates[source].supplier, Path: candidates[source].path})
+	}
+	for i, c := range extracted {
+		if !contributed[i] {
+			trace.Candidates = append(trace.Candidates, Candidate{Supplier: c.supplier, Path: c.path, Value: c.value})
+		}
+	}
+}
+
+// listItems returns the items of the list types produced by merge actions
+func listItems(value interface{}) ([]interface{}, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, true
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items, true
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items, true
+	default:
+		return nil, false
+	}
+}
+
+// sameValue reports whether a candidate value is the merged value, strings are compared trimmed
+func sameValue(value, merged interface{}) bool {
+	if value == nil || merged == nil {
+		return false
+	}
+	return valueKey(value) == valueKey(merged)
+}
+
+// valueKey identifies a value for comparison, as mergeLists does
+func valueKey(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return strings.TrimSpace(str)
+	}
+	return fmt.Sprintf("%v", value)
+}
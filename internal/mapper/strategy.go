@@ -0,0 +1,189 @@
+package mapper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// candidate is the value one supplier provides for a field
+type candidate struct {
+	supplier string
+	path     string // JSON path or template the value was read from
+	weight   float64
+	value    interface{}
+}
+
+// strategyFunc picks the value of a field among the candidates of all suppliers
+// candidates are ordered by supplier: by descending weight then alphabetically, or as listed in "priority"
+type strategyFunc func(m *MappingEngine, candidates []candidate) interface{}
+
+// merge strategies selectable with the "strategy" key of a leaf mapping
+const (
+	strategyLongest       = "longest"
+	strategyShortest      = "shortest"
+	strategyPriority      = "priority"
+	strategyMajority      = "majority"
+	strategyFirstNonEmpty = "first_non_empty"
+	strategyMax           = "max"
+	strategyMin           = "min"
+	strategyUnion         = "union"
+	strategyDefault       = "default" // longest string, otherwise first non-empty value
+)
+
+// strategyRegistry maps the strategy names usable in mapping.json to their implementation
+var strategyRegistry = map[string]strategyFunc{
+	strategyLongest: func(m *MappingEngine, candidates []candidate) interface{} {
+		return m.selectByLength(candidates, func(length, best int) bool { return length > best })
+	},
+	strategyShortest: func(m *MappingEngine, candidates []candidate) interface{} {
+		return m.selectByLength(candidates, func(length, best int) bool { return length < best })
+	},
+	strategyPriority: func(m *MappingEngine, candidates []candidate) interface{} {
+		return m.selectFirstNonEmpty(candidates) // candidates are already in priority order
+	},
+	strategyMajority: func(m *MappingEngine, candidates []candidate) interface{} {
+		return m.selectMajority(candidates)
+	},
+	strategyFirstNonEmpty: func(m *MappingEngine, candidates []candidate) interface{} {
+		return m.selectFirstNonEmpty(candidates)
+	},
+	strategyMax: func(m *MappingEngine, candidates []candidate) interface{} {
+		return m.selectNumber(candidates, func(number, best float64) bool { return number > best })
+	},
+	strategyMin: func(m *MappingEngine, candidates []candidate) interface{} {
+		return m.selectNumber(candidates, func(number, best float64) bool { return number < best })
+	},
+	strategyUnion: func(m *MappingEngine, candidates []candidate) interface{} {
+		return m.mergeLists(candidates)
+	},
+}
+
+// selectBestValue is the strategy used when a leaf mapping has none
+// for strings: the longest non-empty string, otherwise the first non-empty value
+func (m *MappingEngine) selectBestValue(candidates []candidate) interface{} {
+	for _, c := range candidates {
+		if str, ok := c.value.(string); ok && strings.TrimSpace(str) != "" {
+			return m.selectStringBestValue(candidates)
+		}
+	}
+	return m.selectFirstNonEmpty(candidates)
+}
+
+// selectStringBestValue returns the longest non-empty string
+func (m *MappingEngine) selectStringBestValue(candidates []candidate) interface{} {
+	return m.selectByLength(candidates, func(length, best int) bool { return length > best })
+}
+
+// selectByLength returns the trimmed non-empty string for which better holds against every other string,
+// the first non-empty value when no candidate is a string
+func (m *MappingEngine) selectByLength(candidates []candidate, better func(length, best int) bool) interface{} {
+	bestStr := ""
+	found := false
+	for _, c := range candidates {
+		if str, ok := c.value.(string); ok && strings.TrimSpace(str) != "" {
+			trimmedVal := strings.TrimSpace(str)
+			if !found || better(len(trimmedVal), len(bestStr)) {
+				bestStr = trimmedVal
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return m.selectFirstNonEmpty(candidates)
+	}
+	return bestStr
+}
+
+// selectFirstNonEmpty returns the first value that is not nil, blank or an empty list or object
+func (*MappingEngine) selectFirstNonEmpty(candidates []candidate) interface{} {
+	for _, c := range candidates {
+		if !isEmptyValue(c.value) {
+			if str, ok := c.value.(string); ok {
+				return strings.TrimSpace(str)
+			}
+			return c.value
+		}
+	}
+	return nil
+}
+
+// selectMajority returns the value with the highest total supplier weight, ties go to the value seen first
+func (*MappingEngine) selectMajority(candidates []candidate) interface{} {
+	counts := make(map[string]float64)
+	var order []string
+	firstValue := make(map[string]interface{})
+
+	for _, c := range candidates {
+		if isEmptyValue(c.value) {
+			continue
+		}
+		value := c.value
+		if str, ok := value.(string); ok {
+			value = strings.TrimSpace(str)
+		}
+
+		// the type is part of the vote, 5 and "5" are different values
+		key := fmt.Sprintf("%T %s", value, valueKey(value))
+		if _, seen := counts[key]; !seen {
+			order = append(order, key)
+			firstValue[key] = value
+		}
+		counts[key] += c.weight
+	}
+
+	var best string
+	for _, key := range order {
+		if best == "" || counts[key] > counts[best] {
+			best = key
+		}
+	}
+	return firstValue[best]
+}
+
+// selectNumber returns the numeric value for which better holds against every other number
+func (*MappingEngine) selectNumber(candidates []candidate, better func(number, best float64) bool) interface{} {
+	var bestValue interface{}
+	var bestNumber float64
+	for _, c := range candidates {
+		number, ok := toFloat(c.value)
+		if !ok {
+			continue
+		}
+		if bestValue == nil || better(number, bestNumber) {
+			bestValue = c.value
+			bestNumber = number
+		}
+	}
+	return bestValue
+}
+
+// toFloat converts the numeric values produced by extractValue to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// isEmptyValue reports whether a value carries no data
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
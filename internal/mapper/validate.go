@@ -0,0 +1,367 @@
+package mapper
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// reserved keys of a leaf mapping, every other non "src::" key is a field name
+const (
+	actionsKey      = "actions"
+	fieldMappingKey = "field_mapping"
+	strategyKey     = "strategy"
+	priorityKey     = "priority"
+	weightsKey      = "weights"
+	toleranceKey    = "tolerance"
+	typeKey         = "type"
+)
+
+// reserved top level keys, every other top level key is a field name
+const (
+	supplierWeightsKey   = "supplier_weights"   // default weight of each supplier
+	conflictToleranceKey = "conflict_tolerance" // default tolerance of conflict detection
+	vocabulariesKey      = "vocabularies"       // term lists used by the normalize_vocabulary action
+)
+
+// isReservedTopLevelKey reports whether a top level key holds settings instead of a field mapping
+func isReservedTopLevelKey(key string) bool {
+	return key == supplierWeightsKey || key == conflictToleranceKey || key == vocabulariesKey
+}
+
+// defaultWeight is the weight of a supplier without a configured one
+const defaultWeight = 1.0
+
+// ValidationError describes a single problem in the mapping config
+type ValidationError struct {
+	Pointer string // JSON pointer (RFC 6901) to the offending key, e.g. "/location/lat/actions/0"
+	Reason  string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pointer, e.Reason)
+}
+
+// ValidationErrors lists every problem found in the mapping config
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validateConfig checks the mapping config before it is used, so mistakes are reported
+// at startup instead of silently dropping values or panicking while transforming
+func validateConfig(config MappingConfig) ValidationErrors {
+	var errs ValidationErrors
+
+	// the id mapping is what groups supplier records into hotels, every supplier needs a path
+	if idConfig, exists := config["id"]; !exists {
+		errs = append(errs, ValidationError{"/id", "missing id mapping"})
+	} else if idMapping, ok := idConfig.(map[string]interface{}); ok {
+		for _, key := range sortedKeys(idMapping) {
+			if path, ok := idMapping[key].(string); strings.HasPrefix(key, dataSupplierPrefix) && (!ok || strings.TrimSpace(path) == "") {
+				errs = append(errs, ValidationError{jsonPointer("id", key), "id path must be a non-empty string"})
+			}
+		}
+	}
+
+	vocabularies, vocabularyErrs := parseVocabularies(config[vocabulariesKey])
+	errs = append(errs, vocabularyErrs...)
+
+	for _, key := range sortedKeys(config) {
+		switch key {
+		case supplierWeightsKey:
+			suppliers := make(map[string]bool)
+			collectSuppliers(map[string]interface{}(config), suppliers)
+			errs = append(errs, validateWeights(jsonPointer(key), config[key], suppliers)...)
+			continue
+		case conflictToleranceKey:
+			errs = append(errs, validateTolerance(jsonPointer(key), config[key])...)
+			continue
+		case vocabulariesKey:
+			continue
+		}
+		errs = append(errs, validateNode(jsonPointer(key), config[key], vocabularies)...)
+	}
+
+	return errs
+}
+
+// validateNode validates a mapping object, either a leaf with supplier paths or a branch of nested fields
+func validateNode(pointer string, node interface{}, vocabularies map[string]*vocabulary) ValidationErrors {
+	mapping, ok := node.(map[string]interface{})
+	if !ok {
+		return ValidationErrors{{pointer, "expected an object with src:: keys or nested fields"}}
+	}
+	if len(mapping) == 0 {
+		return ValidationErrors{{pointer, "mapping has no src:: keys and no nested fields"}}
+	}
+
+	var errs ValidationErrors
+	isLeaf := isLeafMapping(mapping)
+
+	for _, key := range sortedKeys(mapping) {
+		value := mapping[key]
+		keyPointer := pointer + "/" + escapePointer(key)
+
+		switch {
+		case strings.HasPrefix(key, dataSupplierPrefix):
+			errs = append(errs, validateSupplierPath(keyPointer, value)...)
+		case key == actionsKey || key == fieldMappingKey || key == strategyKey || key == priorityKey || key == weightsKey || key == toleranceKey || key == typeKey:
+			if !isLeaf {
+				errs = append(errs, ValidationError{keyPointer, fmt.Sprintf("reserved key %q used in a mapping without src:: keys", key)})
+			}
+		case isLeaf:
+			if _, isObject := value.(map[string]interface{}); isObject {
+				errs = append(errs, ValidationError{keyPointer, "nested field in a mapping with src:: keys, a mapping is either a leaf or a branch"})
+			} else {
+				errs = append(errs, ValidationError{keyPointer, fmt.Sprintf("unknown key %q in leaf mapping", key)})
+			}
+		default:
+			errs = append(errs, validateNode(keyPointer, value, vocabularies)...)
+		}
+	}
+
+	if isLeaf {
+		errs = append(errs, validateLeafOptions(pointer, mapping, vocabularies)...)
+	}
+
+	return errs
+}
+
+// validateSupplierPath checks a "src::" value is null, a JSON path or a well formed template
+func validateSupplierPath(pointer string, value interface{}) ValidationErrors {
+	if value == nil {
+		return nil
+	}
+
+	path, ok := value.(string)
+	if !ok {
+		return ValidationErrors{{pointer, "supplier path must be a string or null"}}
+	}
+	if strings.TrimSpace(path) == "" {
+		return ValidationErrors{{pointer, "supplier path is empty, use null to skip a supplier"}}
+	}
+	if reason := validateTemplate(path); reason != "" {
+		return ValidationErrors{{pointer, reason}}
+	}
+	return nil
+}
+
+// validateTemplate returns why a template such as "{{Address}}, {{PostalCode}}" is malformed, "" if it is not
+func validateTemplate(template string) string {
+	rest := template
+	for {
+		open := strings.Index(rest, "{{")
+		closing := strings.Index(rest, "}}")
+		switch {
+		case open == -1 && closing == -1:
+			return ""
+		case open == -1 || (closing != -1 && closing < open):
+			return fmt.Sprintf("malformed template %q: \"}}\" without matching \"{{\"", template)
+		case closing == -1:
+			return fmt.Sprintf("malformed template %q: \"{{\" without matching \"}}\"", template)
+		}
+
+		placeholder := rest[open+2 : closing]
+		if strings.TrimSpace(placeholder) == "" {
+			return fmt.Sprintf("malformed template %q: empty placeholder", template)
+		}
+		if strings.ContainsAny(placeholder, "{}") {
+			return fmt.Sprintf("malformed template %q: nested braces in placeholder", template)
+		}
+		rest = rest[closing+2:]
+	}
+}
+
+// validateLeafOptions checks the actions, field_mapping, strategy and priority of a leaf mapping
+func validateLeafOptions(pointer string, mapping map[string]interface{}, vocabularies map[string]*vocabulary) ValidationErrors {
+	var errs ValidationErrors
+	hasMergeImages := false
+	mergeAction := ""
+
+	if rawActions, exists := mapping[actionsKey]; exists {
+		actions, ok := rawActions.([]interface{})
+		if !ok {
+			errs = append(errs, ValidationError{pointer + "/" + actionsKey, "actions must be an array of action names"})
+		}
+		for i, rawAction := range actions {
+			actionPointer := fmt.Sprintf("%s/%s/%d", pointer, actionsKey, i)
+			action, ok := rawAction.(string)
+			if !ok {
+				errs = append(errs, ValidationError{actionPointer, "action must be a string"})
+				continue
+			}
+
+			name := strings.TrimSpace(action)
+			resolved, err := resolveAction(name, vocabularies)
+			switch {
+			case err != nil:
+				errs = append(errs, ValidationError{actionPointer, err.Error()})
+			case resolved.merge == nil: // value actions can be listed freely
+			case mergeAction != "":
+				errs = append(errs, ValidationError{actionPointer, fmt.Sprintf("action %q merges supplier values, %q already does", name, mergeAction)})
+			default:
+				mergeAction = name
+				hasMergeImages = hasMergeImages || name == "merge_image_arrays"
+			}
+		}
+	}
+
+	errs = append(errs, validateStrategy(pointer, mapping, mergeAction)...)
+
+	if rawWeights, exists := mapping[weightsKey]; exists {
+		suppliers := make(map[string]bool)
+		collectSuppliers(mapping, suppliers)
+		errs = append(errs, validateWeights(pointer+"/"+weightsKey, rawWeights, suppliers)...)
+	}
+	if rawTolerance, exists := mapping[toleranceKey]; exists {
+		errs = append(errs, validateTolerance(pointer+"/"+toleranceKey, rawTolerance)...)
+	}
+	if rawType, exists := mapping[typeKey]; exists {
+		if valueType, _ := rawType.(string); !slices.Contains(valueTypes, valueType) {
+			errs = append(errs, ValidationError{pointer + "/" + typeKey, fmt.Sprintf("unknown type %q, expected one of %s", rawType, strings.Join(valueTypes, ", "))})
+		}
+	}
+
+	if rawFieldMapping, exists := mapping[fieldMappingKey]; exists {
+		fieldPointer := pointer + "/" + fieldMappingKey
+		if !hasMergeImages {
+			errs = append(errs, ValidationError{fieldPointer, "field_mapping is only used by the merge_image_arrays action"})
+		}
+
+		fieldMapping, ok := rawFieldMapping.(map[string]interface{})
+		if !ok {
+			errs = append(errs, ValidationError{fieldPointer, "field_mapping must be an object of field name arrays"})
+		}
+		for _, field := range sortedKeys(fieldMapping) {
+			names, ok := fieldMapping[field].([]interface{})
+			valid := ok && len(names) > 0
+			for _, name := range names {
+				if _, isString := name.(string); !isString {
+					valid = false
+				}
+			}
+			if !valid {
+				errs = append(errs, ValidationError{fieldPointer + "/" + escapePointer(field), "expected a non-empty array of supplier field names"})
+			}
+		}
+	} else if hasMergeImages {
+		errs = append(errs, ValidationError{pointer, "merge_image_arrays requires a field_mapping"})
+	}
+
+	return errs
+}
+
+// validateStrategy checks the strategy of a leaf mapping and the supplier list of the priority strategy
+func validateStrategy(pointer string, mapping map[string]interface{}, mergeAction string) ValidationErrors {
+	var errs ValidationErrors
+	strategyPointer := pointer + "/" + strategyKey
+	priorityPointer := pointer + "/" + priorityKey
+
+	rawStrategy, hasStrategy := mapping[strategyKey]
+	strategy, ok := rawStrategy.(string)
+	switch {
+	case !hasStrategy:
+	case !ok:
+		errs = append(errs, ValidationError{strategyPointer, "strategy must be a string"})
+	case strategyRegistry[strategy] == nil:
+		errs = append(errs, ValidationError{strategyPointer, fmt.Sprintf("unknown strategy %q", strategy)})
+	case mergeAction != "":
+		errs = append(errs, ValidationError{strategyPointer, fmt.Sprintf("strategy is ignored, action %q merges supplier values", mergeAction)})
+	}
+
+	rawPriority, hasPriority := mapping[priorityKey]
+	switch {
+	case strategy == strategyPriority && !hasPriority:
+		errs = append(errs, ValidationError{pointer, "strategy \"priority\" requires a priority list of suppliers"})
+	case hasPriority && strategy != strategyPriority:
+		errs = append(errs, ValidationError{priorityPointer, "priority is only used by strategy \"priority\""})
+	case hasPriority:
+		priority, ok := rawPriority.([]interface{})
+		if !ok || len(priority) == 0 {
+			errs = append(errs, ValidationError{priorityPointer, "priority must be a non-empty array of supplier names"})
+		}
+		for i, rawSupplier := range priority {
+			supplier, ok := rawSupplier.(string)
+			if _, mapped := mapping[dataSupplierPrefix+supplier]; !ok || !mapped {
+				errs = append(errs, ValidationError{fmt.Sprintf("%s/%d", priorityPointer, i), fmt.Sprintf("%v is not a supplier of this mapping", rawSupplier)})
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateWeights checks a weights object maps known suppliers to positive numbers
+func validateWeights(pointer string, value interface{}, suppliers map[string]bool) ValidationErrors {
+	weights, ok := value.(map[string]interface{})
+	if !ok {
+		return ValidationErrors{{pointer, "weights must be an object of supplier names to numbers"}}
+	}
+
+	var errs ValidationErrors
+	for _, supplier := range sortedKeys(weights) {
+		weightPointer := pointer + "/" + escapePointer(supplier)
+		if weight, ok := weights[supplier].(float64); !ok || weight <= 0 {
+			errs = append(errs, ValidationError{weightPointer, "weight must be a positive number"})
+		}
+		if !suppliers[supplier] {
+			errs = append(errs, ValidationError{weightPointer, fmt.Sprintf("%s is not a supplier of this mapping", supplier)})
+		}
+	}
+	return errs
+}
+
+// validateTolerance checks a tolerance object only holds a non-negative numeric_epsilon and a string_similarity within 0..1
+func validateTolerance(pointer string, value interface{}) ValidationErrors {
+	settings, ok := value.(map[string]interface{})
+	if !ok {
+		return ValidationErrors{{pointer, "tolerance must be an object"}}
+	}
+
+	var errs ValidationErrors
+	for _, key := range sortedKeys(settings) {
+		settingPointer := pointer + "/" + escapePointer(key)
+		number, isNumber := settings[key].(float64)
+		switch {
+		case key != "numeric_epsilon" && key != "string_similarity" && key != "distance_km":
+			errs = append(errs, ValidationError{settingPointer, fmt.Sprintf("unknown tolerance %q", key)})
+		case !isNumber || number < 0:
+			errs = append(errs, ValidationError{settingPointer, "tolerance must be a non-negative number"})
+		case key == "string_similarity" && number > 1:
+			errs = append(errs, ValidationError{settingPointer, "string_similarity must be between 0 and 1"})
+		}
+	}
+	return errs
+}
+
+// jsonPointer builds a JSON pointer from unescaped keys
+func jsonPointer(keys ...string) string {
+	var pointer strings.Builder
+	for _, key := range keys {
+		pointer.WriteString("/")
+		pointer.WriteString(escapePointer(key))
+	}
+	return pointer.String()
+}
+
+// escapePointer escapes a key for use in a JSON pointer
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// sortedKeys returns the keys of a mapping in a stable order
+func sortedKeys[V any](mapping map[string]V) []string {
+	keys := make([]string, 0, len(mapping))
+	for key := range mapping {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
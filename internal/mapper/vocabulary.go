@@ -0,0 +1,451 @@
+package mapper
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// vocabulary is a list of canonical terms declared under "vocabularies" in mapping.json, e.g.
+//
+//	"amenities": {
+//	    "order": "vocabulary",
+//	    "max_distance": 1,
+//	    "stop_words": ["free"],
+//	    "terms": [
+//	        {"term": "business center", "category": "general", "synonyms": ["businesscenter"]}
+//	    ]
+//	}
+//
+// a plain array of terms is accepted as well, with the default matching settings
+// supplier values matching a term or one of its synonyms are replaced by the term, other values are discarded
+type vocabulary struct {
+	name        string
+	terms       []vocabularyTerm // in declaration order
+	lookup      map[string]int   // key: termKey of a term or synonym, value: index in terms
+	lookupKeys  []string         // keys of lookup, sorted once parsed so fuzzy matching is deterministic
+	categories  map[string]bool
+	order       string          // order of the normalized terms, one of the vocabularyOrders
+	maxDistance int             // edit distance accepted when no term matches exactly, 0 disables fuzzy matching
+	stopWords   map[string]bool // words ignored when matching, e.g. "free" in "free wifi"
+
+	fuzzyMu      sync.Mutex
+	fuzzyMatches map[string]int // key: termKey without an exact match, value: index in terms or -1, see resolve
+}
+
+// vocabularyTerm is a canonical term of a vocabulary
+type vocabularyTerm struct {
+	term     string
+	category string // optional, e.g. "general" or "room"
+}
+
+// orders of the normalized terms
+const (
+	orderVocabulary   = "vocabulary"   // as declared in the vocabulary
+	orderAlphabetical = "alphabetical" // by term
+	orderFirstSeen    = "first_seen"   // as sent by the suppliers, highest weight first
+)
+
+var vocabularyOrders = []string{orderVocabulary, orderAlphabetical, orderFirstSeen}
+
+const (
+	defaultMaxDistance = 1
+	minFuzzyLength     = 5    // shorter keys must match exactly, "bar" is one edit away from "car"
+	maxFuzzyMatches    = 4096 // fuzzy matches cached per vocabulary, the cache is emptied when full
+)
+
+// parseVocabularies reads the "vocabularies" object of the mapping, problems are reported with their JSON pointer
+func parseVocabularies(value interface{}) (map[string]*vocabulary, ValidationErrors) {
+	vocabularies := make(map[string]*vocabulary)
+	if value == nil {
+		return vocabularies, nil
+	}
+
+	pointer := jsonPointer(vocabulariesKey)
+	declared, ok := value.(map[string]interface{})
+	if !ok {
+		return vocabularies, ValidationErrors{{pointer, "vocabularies must be an object of term lists"}}
+	}
+
+	var errs ValidationErrors
+	for _, name := range sortedKeys(declared) {
+		vocab, vocabErrs := parseVocabulary(pointer+"/"+escapePointer(name), name, declared[name])
+		errs = append(errs, vocabErrs...)
+		vocabularies[name] = vocab
+	}
+	return vocabularies, errs
+}
+
+// parseVocabulary reads a single vocabulary, either an array of terms or an object with settings and "terms"
+func parseVocabulary(pointer, name string, value interface{}) (*vocabulary, ValidationErrors) {
+	vocab := &vocabulary{
+		name:        name,
+		lookup:      make(map[string]int),
+		categories:  make(map[string]bool),
+		order:       orderVocabulary,
+		maxDistance: defaultMaxDistance,
+		stopWords:   make(map[string]bool),
+	}
+
+	var errs ValidationErrors
+	entries, ok := value.([]interface{})
+	termsPointer := pointer
+	if settings, isObject := value.(map[string]interface{}); isObject {
+		termsPointer = pointer + "/terms"
+		entries, ok = settings["terms"].([]interface{})
+		errs = append(errs, vocab.parseSettings(pointer, settings)...)
+	}
+	if !ok {
+		return vocab, append(errs, ValidationError{termsPointer, "vocabulary must be an array of terms"})
+	}
+
+	for i, rawEntry := range entries {
+		errs = append(errs, vocab.parseTerm(fmt.Sprintf("%s/%d", termsPointer, i), rawEntry)...)
+	}
+	vocab.lookupKeys = sortedKeys(vocab.lookup)
+	return vocab, errs
+}
+
+// parseSettings reads the matching settings of a vocabulary declared as an object
+func (v *vocabulary) parseSettings(pointer string, settings map[string]interface{}) ValidationErrors {
+	var errs ValidationErrors
+	for _, key := range sortedKeys(settings) {
+		settingPointer := pointer + "/" + escapePointer(key)
+		switch key {
+		case "terms":
+		case "order":
+			order, _ := settings[key].(string)
+			if !slices.Contains(vocabularyOrders, order) {
+				errs = append(errs, ValidationError{settingPointer, fmt.Sprintf("order must be one of %s", strings.Join(vocabularyOrders, ", "))})
+				continue
+			}
+			v.order = order
+		case "max_distance":
+			distance, ok := settings[key].(float64)
+			if !ok || distance < 0 || distance != float64(int(distance)) {
+				errs = append(errs, ValidationError{settingPointer, "max_distance must be a non-negative integer"})
+				continue
+			}
+			v.maxDistance = int(distance)
+		case "stop_words":
+			words, ok := settings[key].([]interface{})
+			if !ok {
+				errs = append(errs, ValidationError{settingPointer, "stop_words must be an array of words"})
+				continue
+			}
+			for i, rawWord := range words {
+				word, ok := rawWord.(string)
+				if !ok || len(termTokens(word, nil)) != 1 {
+					errs = append(errs, ValidationError{fmt.Sprintf("%s/%d", settingPointer, i), "stop word must be a single word"})
+					continue
+				}
+				v.stopWords[termTokens(word, nil)[0]] = true
+			}
+		default:
+			errs = append(errs, ValidationError{settingPointer, fmt.Sprintf("unknown key %q in vocabulary", key)})
+		}
+	}
+	return errs
+}
+
+// parseTerm reads a canonical term with its category and synonyms
+func (v *vocabulary) parseTerm(pointer string, rawEntry interface{}) ValidationErrors {
+	entry, ok := rawEntry.(map[string]interface{})
+	if !ok {
+		return ValidationErrors{{pointer, `term must be an object with "term", "category" and "synonyms"`}}
+	}
+
+	term, ok := entry["term"].(string)
+	if !ok || strings.TrimSpace(term) == "" {
+		return ValidationErrors{{pointer + "/term", "term must be a non-empty string"}}
+	}
+
+	var errs ValidationErrors
+	category, ok := entry["category"].(string)
+	if _, exists := entry["category"]; exists && !ok {
+		errs = append(errs, ValidationError{pointer + "/category", "category must be a string"})
+	}
+	for _, key := range sortedKeys(entry) {
+		if key != "term" && key != "category" && key != "synonyms" {
+			errs = append(errs, ValidationError{pointer + "/" + escapePointer(key), fmt.Sprintf("unknown key %q in vocabulary term", key)})
+		}
+	}
+
+	index := len(v.terms)
+	v.terms = append(v.terms, vocabularyTerm{term: strings.TrimSpace(term), category: category})
+	if category != "" {
+		v.categories[category] = true
+	}
+
+	words := []string{term}
+	rawSynonyms, _ := entry["synonyms"].([]interface{})
+	if _, exists := entry["synonyms"]; exists && rawSynonyms == nil {
+		errs = append(errs, ValidationError{pointer + "/synonyms", "synonyms must be an array of strings"})
+	}
+	for j, rawSynonym := range rawSynonyms {
+		synonym, ok := rawSynonym.(string)
+		if !ok || strings.TrimSpace(synonym) == "" {
+			errs = append(errs, ValidationError{fmt.Sprintf("%s/synonyms/%d", pointer, j), "synonym must be a non-empty string"})
+			continue
+		}
+		words = append(words, synonym)
+	}
+
+	for _, word := range words {
+		key := v.termKey(word)
+		if key == "" {
+			errs = append(errs, ValidationError{pointer, fmt.Sprintf("%q only consists of stop words and punctuation", word)})
+			continue
+		}
+		if other, exists := v.lookup[key]; exists && other != index {
+			errs = append(errs, ValidationError{pointer, fmt.Sprintf("%q already maps to %q", word, v.terms[other].term)})
+			continue
+		}
+		v.lookup[key] = index
+	}
+	return errs
+}
+
+// termKey is the form in which terms and supplier values are compared:
+// "  Business-Centre " and "BusinessCenter" both become "businesscenter"
+func (v *vocabulary) termKey(value string) string {
+	return strings.Join(termTokens(value, v.stopWords), "")
+}
+
+// termTokens splits a term into lowercase words: camel case is split, punctuation is dropped,
+// British spellings are folded to American ones and stop words are removed
+func termTokens(value string, stopWords map[string]bool) []string {
+	var spaced strings.Builder
+	var previous rune
+	for _, r := range strings.TrimSpace(value) {
+		if unicode.IsUpper(r) && unicode.IsLower(previous) {
+			spaced.WriteRune(' ') // "BusinessCenter" -> "Business Center"
+		}
+		spaced.WriteRune(r)
+		previous = r
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(spaced.String()), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := words[:0]
+	for _, word := range words {
+		word = foldSpelling(word)
+		if !stopWords[word] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// foldSpelling maps common British spellings to American ones: centre -> center, colour -> color, sanitise -> sanitize
+func foldSpelling(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "tre"):
+		return strings.TrimSuffix(word, "tre") + "ter"
+	case len(word) > 4 && strings.HasSuffix(word, "our"):
+		return strings.TrimSuffix(word, "our") + "or"
+	case len(word) > 4 && strings.HasSuffix(word, "ise"):
+		return strings.TrimSuffix(word, "ise") + "ize"
+	case len(word) > 4 && strings.HasSuffix(word, "yse"):
+		return strings.TrimSuffix(word, "yse") + "yze"
+	default:
+		return word
+	}
+}
+
+// resolve finds the term of a raw supplier value: an exact match of its key, otherwise the closest term within
+// maxDistance edits, ties going to the term declared first
+func (v *vocabulary) resolve(value string) int {
+	key := v.termKey(value)
+	if index, exists := v.lookup[key]; exists {
+		return index
+	}
+	if utf8.RuneCountInString(key) < minFuzzyLength || v.maxDistance <= 0 {
+		return -1
+	}
+
+	// comparing with every term is costly, the results are cached, in a bounded cache as the engine
+	// lives as long as the process and suppliers keep sending new spellings
+	v.fuzzyMu.Lock()
+	index, cached := v.fuzzyMatches[key]
+	v.fuzzyMu.Unlock()
+	if cached {
+		return index
+	}
+
+	index = -1
+	best := v.maxDistance + 1
+	for _, termKey := range v.lookupKeys {
+		// the limit is one above best so a distance equal to best is exact, not "at least best"
+		if distance := levenshtein(key, termKey, best+1); distance < best ||
+			(distance == best && distance <= v.maxDistance && v.lookup[termKey] < index) {
+			best, index = distance, v.lookup[termKey]
+		}
+	}
+
+	v.fuzzyMu.Lock()
+	if v.fuzzyMatches == nil || len(v.fuzzyMatches) >= maxFuzzyMatches {
+		v.fuzzyMatches = make(map[string]int)
+	}
+	v.fuzzyMatches[key] = index
+	v.fuzzyMu.Unlock()
+	return index
+}
+
+// levenshtein returns the edit distance in runes between a and b, or limit when it is at least limit
+func levenshtein(a, b string, limit int) int {
+	runesA, runesB := []rune(a), []rune(b)
+	if abs(len(runesA)-len(runesB)) >= limit {
+		return limit
+	}
+
+	previous := make([]int, len(runesB)+1)
+	current := make([]int, len(runesB)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(runesA); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(runesB); j++ {
+			substitution := previous[j-1]
+			if runesA[i-1] != runesB[j-1] {
+				substitution++
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin >= limit {
+			return limit
+		}
+		previous, current = current, previous
+	}
+	return min(previous[len(runesB)], limit)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// normalizeVocabularyAction builds the "normalize_vocabulary:<vocabulary>[:<category>]" action,
+// it merges the lists of all suppliers and keeps the canonical terms of the values found in the vocabulary
+func normalizeVocabularyAction(params []string, vocabularies map[string]*vocabulary) (actionPlan, error) {
+	if len(params) < 1 || len(params) > 2 {
+		return actionPlan{}, fmt.Errorf("expected normalize_vocabulary:<vocabulary> or normalize_vocabulary:<vocabulary>:<category>")
+	}
+
+	vocab, exists := vocabularies[params[0]]
+	if !exists {
+		return actionPlan{}, fmt.Errorf("unknown vocabulary %q", params[0])
+	}
+	category := ""
+	if len(params) == 2 {
+		category = params[1]
+		if !vocab.categories[category] {
+			return actionPlan{}, fmt.Errorf("vocabulary %q has no category %q", vocab.name, category)
+		}
+	}
+
+	return actionPlan{merge: func(m *MappingEngine, candidates []candidate, _ *fieldPlan, recorder *hotelRecorder) interface{} {
+		return m.normalizeVocabulary(candidates, vocab, category, recorder)
+	}}, nil
+}
+
+// normalizeVocabulary maps the values of all suppliers to the canonical terms of the vocabulary, in the order of the vocabulary
+// values of another category are skipped, values matching no term at all are recorded as unmatched
+func (m *MappingEngine) normalizeVocabulary(candidates []candidate, vocab *vocabulary, category string, recorder *hotelRecorder) interface{} {
+	var matched []int // indexes in terms, first seen first
+	seen := make(map[int]bool)
+
+	for _, c := range candidates {
+		values, isList := c.value.([]interface{})
+		if !isList {
+			values = []interface{}{c.value}
+		}
+
+		for _, v := range values {
+			str, ok := v.(string)
+			if !ok {
+				continue
+			}
+
+			index := vocab.resolve(str)
+			switch {
+			case index == -1:
+				recorder.recordUnmatched(unmatchedTerm{vocabulary: vocab.name, term: strings.TrimSpace(str), supplier: c.supplier})
+			case seen[index]:
+			case category == "" || vocab.terms[index].category == category:
+				seen[index] = true
+				matched = append(matched, index)
+			}
+		}
+	}
+
+	switch vocab.order {
+	case orderVocabulary:
+		slices.Sort(matched)
+	case orderAlphabetical:
+		slices.SortFunc(matched, func(a, b int) int { return strings.Compare(vocab.terms[a].term, vocab.terms[b].term) })
+	}
+
+	terms := make([]string, len(matched))
+	for i, index := range matched {
+		terms[i] = vocab.terms[index].term
+	}
+	return terms
+}
+
+// unmatchedTerm is a supplier value of a single hotel that is not in its vocabulary
+type unmatchedTerm struct {
+	vocabulary string
+	term       string
+	supplier   string
+}
+
+// UnmatchedTerm is a supplier value found in no term of a vocabulary, a candidate to add to mapping.json
+type UnmatchedTerm struct {
+	Vocabulary string `json:"vocabulary"`
+	Supplier   string `json:"supplier"`
+	Term       string `json:"term"`  // as sent by the supplier, trimmed
+	Count      int    `json:"count"` // number of hotels the supplier sent it for
+}
+
+// recordUnmatched keeps an unmatched term once per hotel and supplier, a nil recorder discards it
+func (r *hotelRecorder) recordUnmatched(term unmatchedTerm) {
+	if r == nil || slices.Contains(r.unmatched, term) {
+		return
+	}
+	r.unmatched = append(r.unmatched, term)
+}
+
+// countUnmatched aggregates the unmatched terms of all hotels per supplier,
+// most frequent first then by vocabulary, supplier and term
+func countUnmatched(terms []unmatchedTerm) []UnmatchedTerm {
+	counts := make(map[unmatchedTerm]int)
+	for _, term := range terms {
+		counts[term]++
+	}
+
+	unmatched := make([]UnmatchedTerm, 0, len(counts))
+	for term, count := range counts {
+		unmatched = append(unmatched, UnmatchedTerm{Vocabulary: term.vocabulary, Supplier: term.supplier, Term: term.term, Count: count})
+	}
+	slices.SortFunc(unmatched, func(a, b UnmatchedTerm) int {
+		return cmp.Or(
+			b.Count-a.Count,
+			strings.Compare(a.Vocabulary, b.Vocabulary),
+			strings.Compare(a.Supplier, b.Supplier),
+			strings.Compare(a.Term, b.Term),
+		)
+	})
+	return unmatched
+}
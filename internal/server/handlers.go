@@ -1,10 +1,17 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/ptrciafae/hotels-merge/internal/hotels"
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
 )
 
 type Handlers struct {
@@ -16,34 +23,222 @@ func NewHandlers(store *hotels.HotelStore) *Handlers {
 }
 
 func (h *Handlers) handleQueryHotels(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	var result hotels.Hotels
+	criteria, err := parseCriteria(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	ids := query.Get("ids")
-	destinationIds := query.Get("destination_ids")
+	h.writeSearch(w, r, criteria)
+}
 
-	if ids == "" && destinationIds == "" {
-		h.handleGetAllHotels(w, r)
+func (h *Handlers) handleGetAllHotels(w http.ResponseWriter, r *http.Request) {
+	h.writeSearch(w, r, nil)
+}
+
+// writeSearch writes one page of the hotels matching criteria
+// the body stays a plain array, the total count and the next page are given in headers
+func (h *Handlers) writeSearch(w http.ResponseWriter, r *http.Request, criteria []hotels.Criterion) {
+	query, err := parsePagination(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	query.Criteria = criteria
+
+	page := h.store.Search(query)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if next := query.Offset + len(page.Hotels); query.Limit > 0 && next < page.Total {
+		nextURL := *r.URL
+		values := nextURL.Query()
+		values.Set("offset", strconv.Itoa(next))
+		nextURL.RawQuery = values.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, nextURL.RequestURI()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.Hotels)
+}
+
+// parsePagination reads the sort, limit and offset query parameters
+func parsePagination(query url.Values) (hotels.Query, error) {
+	var result hotels.Query
+
+	sortKey, err := hotels.ParseSortKey(query.Get("sort"))
+	if err != nil {
+		return result, err
+	}
+	result.Sort = sortKey
+
+	if limit := query.Get("limit"); limit != "" {
+		result.Limit, err = strconv.Atoi(limit)
+		if err != nil || result.Limit <= 0 {
+			return result, fmt.Errorf("invalid limit %q, expected a positive number", limit)
+		}
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		result.Offset, err = strconv.Atoi(offset)
+		if err != nil || result.Offset < 0 {
+			return result, fmt.Errorf("invalid offset %q, expected a non-negative number", offset)
+		}
+	}
+
+	return result, nil
+}
+
+// parseCriteria builds the store criteria from the supported query parameters
+// every supplied parameter narrows down the result, comma separated values within a parameter widen it
+func parseCriteria(query url.Values) ([]hotels.Criterion, error) {
+	var criteria []hotels.Criterion
+
+	ids, err := listParam(query, "ids")
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		criteria = append(criteria, hotels.ByIds(ids...))
+	}
+
+	values, err := listParam(query, "destination_ids")
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > 0 {
+		destinationIds := make([]int, 0, len(values))
+		for _, value := range values {
+			destinationId, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid destination_ids value %q", value)
+			}
+			destinationIds = append(destinationIds, destinationId)
+		}
+		criteria = append(criteria, hotels.ByDestinations(destinationIds...))
+	}
+
+	return criteria, nil
+}
 
-	if ids != "" && destinationIds != "" {
-		http.Error(w, "Only one query parameter (ids or destination_ids) can be provided at a time", http.StatusBadRequest)
+// listParam reads a comma separated query parameter, an empty value, e.g. "?ids=", means no filter,
+// while separators without values, e.g. "?ids=,,", are an error rather than a filter returning every hotel
+func listParam(query url.Values, name string) ([]string, error) {
+	param := strings.TrimSpace(query.Get(name))
+	if param == "" {
+		return nil, nil
+	}
+	values := splitList(param)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s must list at least one value", name)
+	}
+	return values, nil
+}
+
+// splitList splits a comma separated query parameter, dropping empty values
+func splitList(param string) []string {
+	var values []string
+	for _, value := range strings.Split(param, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (h *Handlers) handleGetHotel(w http.ResponseWriter, r *http.Request) {
+	hotel, found := h.store.Get(r.PathValue("id"))
+	if !found {
+		writeError(w, http.StatusNotFound, "hotel not found")
 		return
 	}
 
-	if ids != "" {
-		result = h.store.FilterByIds(ids)
-	} else if destinationIds := query.Get("destination_ids"); destinationIds != "" {
-		result = h.store.FilterByDestinations(destinationIds)
+	body, err := json.Marshal(hotel)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode hotel")
+		return
+	}
+
+	// the ETag is derived from the content, so it only changes when a refresh changes this hotel
+	etag := contentETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	w.Write(body)
 }
 
-func (h *Handlers) handleGetAllHotels(w http.ResponseWriter, r *http.Request) {
-	result := h.store.GetAll()
+// provenanceResponse is the body of /hotels/{id}/provenance
+type provenanceResponse struct {
+	Id     string                 `json:"id"`
+	Fields mapper.HotelProvenance `json:"fields"`
+}
+
+func (h *Handlers) handleGetHotelProvenance(w http.ResponseWriter, r *http.Request) {
+	hotel, found := h.store.Get(r.PathValue("id"))
+	if !found {
+		writeError(w, http.StatusNotFound, "hotel not found")
+		return
+	}
+
+	provenance, recorded := h.store.Provenance(hotel.Id)
+	if !recorded {
+		writeError(w, http.StatusNotFound, "provenance is not recorded, enable it in config.json")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	json.NewEncoder(w).Encode(provenanceResponse{Id: hotel.Id, Fields: provenance})
+}
+
+func (h *Handlers) handleGetIngestionReport(w http.ResponseWriter, r *http.Request) {
+	report := h.store.Report()
+	if report == nil {
+		writeError(w, http.StatusServiceUnavailable, "no ingestion has run yet")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *Handlers) handleGetConflicts(w http.ResponseWriter, r *http.Request) {
+	conflicts := h.store.Conflicts()
+	if conflicts == nil {
+		writeError(w, http.StatusNotFound, "conflict detection is not enabled, enable it in config.json")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hotels.NewConflictReport(conflicts))
+}
+
+// errorResponse is the body of every JSON error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: message})
+}
+
+// contentETag returns a strong ETag for the given response body
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches the given ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
 }
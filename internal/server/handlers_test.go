@@ -0,0 +1,269 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ptrciafae/hotels-merge/internal/hotels"
+	"github.com/ptrciafae/hotels-merge/internal/mapper"
+	"github.com/ptrciafae/hotels-merge/internal/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*hotels.HotelStore, http.Handler) {
+	t.Helper()
+	store := hotels.NewHotelStore()
+	store.Set(hotels.Hotels{
+		{Id: "iJhz", DestinationId: 5432, Name: "Beach Villas Singapore"},
+		{Id: "SjyX", DestinationId: 5432, Name: "InterContinental Singapore Robertson Quay"},
+		{Id: "f8c9", DestinationId: 1122, Name: "Hilton Shinjuku"},
+	})
+	return store, server.New(store).Handler()
+}
+
+func serve(handler http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetHotel(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/hotels/f8c9", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+
+	var hotel hotels.Hotel
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &hotel))
+	assert.Equal(t, "Hilton Shinjuku", hotel.Name)
+}
+
+func TestGetHotel_NotFound(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/hotels/missing", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error": "hotel not found"}`, rec.Body.String())
+}
+
+func TestGetHotel_ETag(t *testing.T) {
+	store, handler := newTestServer(t)
+
+	etag := serve(handler, http.MethodGet, "/hotels/f8c9", nil).Header().Get("ETag")
+
+	rec := serve(handler, http.MethodGet, "/hotels/f8c9", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// refreshing with identical content keeps the ETag, changing the hotel does not
+	store.Set(hotels.Hotels{{Id: "f8c9", DestinationId: 1122, Name: "Hilton Shinjuku"}})
+	assert.Equal(t, etag, serve(handler, http.MethodGet, "/hotels/f8c9", nil).Header().Get("ETag"))
+
+	store.Set(hotels.Hotels{{Id: "f8c9", DestinationId: 1122, Name: "Hilton Tokyo"}})
+	rec = serve(handler, http.MethodGet, "/hotels/f8c9", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+}
+
+func TestGetHotelProvenance(t *testing.T) {
+	store, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/hotels/f8c9/provenance", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error": "provenance is not recorded, enable it in config.json"}`, rec.Body.String())
+
+	store.SetDataset(&hotels.Dataset{
+		Hotels: hotels.Hotels{{Id: "f8c9", DestinationId: 1122, Name: "Hilton Shinjuku"}},
+		Provenance: map[string]mapper.HotelProvenance{
+			"f8c9": {"name": {Supplier: "acme", Path: "Name", Candidates: []mapper.Candidate{{Supplier: "paperflies", Path: "hotel_name", Value: "Hilton"}}}},
+		},
+	})
+
+	rec = serve(handler, http.MethodGet, "/hotels/f8c9/provenance", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"id": "f8c9",
+		"fields": {
+			"name": {"supplier": "acme", "path": "Name", "candidates": [{"supplier": "paperflies", "path": "hotel_name", "value": "Hilton"}]}
+		}
+	}`, rec.Body.String())
+
+	rec = serve(handler, http.MethodGet, "/hotels/missing/provenance", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error": "hotel not found"}`, rec.Body.String())
+}
+
+func TestGetIngestionReport(t *testing.T) {
+	store, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/admin/ingestion", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"error": "no ingestion has run yet"}`, rec.Body.String())
+
+	lastSuccess := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	store.SetReport(&hotels.IngestionReport{
+		Hotels: 3,
+		Suppliers: []hotels.SupplierReport{
+			{Name: "acme", Status: hotels.SupplierOK, HTTPStatus: http.StatusOK, Attempts: 1, Hotels: 3, LastSuccessAt: &lastSuccess},
+			{Name: "paperflies", Status: hotels.SupplierFailed, HTTPStatus: http.StatusBadGateway, Attempts: 3, Error: "unexpected status 502"},
+		},
+	})
+
+	rec = serve(handler, http.MethodGet, "/admin/ingestion", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report struct {
+		Hotels    int                      `json:"hotels"`
+		Suppliers []map[string]interface{} `json:"suppliers"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, 3, report.Hotels)
+	require.Len(t, report.Suppliers, 2)
+
+	acme := report.Suppliers[0]
+	assert.Equal(t, "acme", acme["name"])
+	assert.Equal(t, "ok", acme["status"])
+	assert.Equal(t, float64(1), acme["attempts"])
+	assert.Equal(t, "2024-05-01T08:30:00Z", acme["last_success_at"])
+
+	paperflies := report.Suppliers[1]
+	assert.Equal(t, "paperflies", paperflies["name"])
+	assert.Equal(t, "failed", paperflies["status"])
+	assert.Equal(t, float64(3), paperflies["attempts"])
+	assert.Equal(t, "unexpected status 502", paperflies["error"])
+	assert.NotContains(t, paperflies, "last_success_at", "a supplier that never succeeded has no last success")
+}
+
+func TestGetConflicts(t *testing.T) {
+	store, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/admin/conflicts", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	store.SetDataset(&hotels.Dataset{
+		Hotels: hotels.Hotels{{Id: "f8c9", DestinationId: 1122, Name: "Hilton Shinjuku"}},
+		Conflicts: []mapper.Conflict{{
+			HotelId:    "f8c9",
+			Field:      "location.country",
+			Values:     map[string]interface{}{"acme": "JP", "paperflies": "Japan"},
+			Resolution: "default",
+			Value:      "Japan",
+			Supplier:   "paperflies",
+		}},
+	})
+
+	rec = serve(handler, http.MethodGet, "/admin/conflicts", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"total": 1,
+		"conflicts": [{
+			"hotel_id": "f8c9",
+			"field": "location.country",
+			"values": {"acme": "JP", "paperflies": "Japan"},
+			"resolution": "default",
+			"value": "Japan",
+			"supplier": "paperflies"
+		}]
+	}`, rec.Body.String())
+}
+
+func TestQueryHotels_CombinesFilters(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/hotels?ids=iJhz,f8c9&destination_ids=5432", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var result hotels.Hotels
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	require.Len(t, result, 1)
+	assert.Equal(t, "iJhz", result[0].Id)
+}
+
+func TestQueryHotels_InvalidDestinationId(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/hotels?destination_ids=5432,abc", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error": "invalid destination_ids value \"abc\""}`, rec.Body.String())
+}
+
+func TestQueryHotels_EmptyFilter(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/hotels?ids=,,,", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error": "ids must list at least one value"}`, rec.Body.String())
+}
+
+func TestQueryHotels_EmptyValueIsNoFilter(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	all := serve(handler, http.MethodGet, "/hotels", nil)
+	rec := serve(handler, http.MethodGet, "/hotels?ids=&destination_ids=", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, all.Body.String(), rec.Body.String())
+}
+
+func TestQueryHotels_Pagination(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/hotels?destination_ids=5432,1122&sort=name&limit=2", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</hotels?destination_ids=5432%2C1122&limit=2&offset=2&sort=name>; rel="next"`, rec.Header().Get("Link"))
+
+	var result hotels.Hotels
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	require.Len(t, result, 2)
+	assert.Equal(t, "Beach Villas Singapore", result[0].Name)
+	assert.Equal(t, "Hilton Shinjuku", result[1].Name)
+
+	rec = serve(handler, http.MethodGet, "/hotels?destination_ids=5432,1122&sort=name&limit=2&offset=2", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Link"))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	require.Len(t, result, 1)
+	assert.Equal(t, "InterContinental Singapore Robertson Quay", result[0].Name)
+}
+
+func TestGetAllHotels_DefaultsToIdOrder(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get("X-Total-Count"))
+
+	var result hotels.Hotels
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	require.Len(t, result, 3)
+	assert.Equal(t, []string{"SjyX", "f8c9", "iJhz"}, []string{result[0].Id, result[1].Id, result[2].Id})
+}
+
+func TestQueryHotels_EmptyResultIsAnArray(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	rec := serve(handler, http.MethodGet, "/hotels?ids=missing", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+}
+
+func TestQueryHotels_InvalidPagination(t *testing.T) {
+	_, handler := newTestServer(t)
+
+	for _, target := range []string{"/hotels?limit=0", "/hotels?offset=-1", "/?sort=price"} {
+		rec := serve(handler, http.MethodGet, target, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+	}
+}
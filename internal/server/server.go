@@ -23,6 +23,12 @@ func New(store *hotels.HotelStore) *Server {
 
 	// config routes
 	mux.HandleFunc("GET /hotels", handlers.handleQueryHotels)
+	mux.HandleFunc("GET /hotels/{id}", handlers.handleGetHotel)
+	mux.HandleFunc("GET /hotels/{id}/provenance", handlers.handleGetHotelProvenance)
+
+	// admin routes
+	mux.HandleFunc("GET /admin/ingestion", handlers.handleGetIngestionReport)
+	mux.HandleFunc("GET /admin/conflicts", handlers.handleGetConflicts)
 
 	srv := &http.Server{
 		Addr:         "127.0.0.1:8085",
@@ -38,6 +44,11 @@ func New(store *hotels.HotelStore) *Server {
 	}
 }
 
+// Handler returns the router serving all routes
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
 func (s *Server) Start() error {
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("failed to start server: %w", err)